@@ -0,0 +1,84 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"os"
+
+	"github.com/spf13/afero"
+
+	"github.com/bitmark-inc/datapod/data-parser/schema/facebook"
+	"github.com/bitmark-inc/datapod/data-parser/storage"
+)
+
+// fileDrift is how a file of an archive drifts from the raw type of its pattern.
+type fileDrift struct {
+	Pattern string `json:"pattern"`
+	File    string `json:"file"`
+	Version string `json:"version"`
+	*facebook.Drift
+}
+
+// runDrift compares the files of a local archive with the raw types of their patterns,
+// and writes the drift of each file as a JSON line to stdout. Nothing is parsed or persisted.
+func runDrift(args []string) error {
+	flags := flag.NewFlagSet("drift", flag.ExitOnError)
+	archivePath := flags.String("archive", "", "path to the archive zip file")
+	patterns := flags.String("patterns", "", "comma-separated names of the patterns to analyze, defaults to all")
+	flags.Parse(args)
+
+	if *archivePath == "" {
+		flags.Usage()
+		return errors.New("archive is required")
+	}
+
+	fs, err := storage.OpenZipFs(*archivePath)
+	if err != nil {
+		return err
+	}
+	defer fs.Close()
+
+	handlers, err := registryOf(fs).Handlers(storage.SplitPatternNames(*patterns)...)
+	if err != nil {
+		return err
+	}
+
+	parseCtx := facebook.NewParseContext(nil, "", "", nil, nil, "")
+	enc := json.NewEncoder(os.Stdout)
+	for _, h := range handlers {
+		pattern := h.Pattern()
+		if pattern.Regexp == nil || h.RawType() == nil {
+			continue
+		}
+
+		files, err := pattern.SelectFiles(fs, pattern.Location)
+		if err != nil {
+			return err
+		}
+		for _, file := range files {
+			data, err := afero.ReadFile(fs, file)
+			if err != nil {
+				return err
+			}
+			data, version, err := parseCtx.UpgradeFile(pattern, data)
+			if err != nil {
+				return err
+			}
+			d, err := facebook.AnalyzeDrift(h.RawType(), data)
+			if err != nil {
+				return err
+			}
+
+			if err := enc.Encode(fileDrift{
+				Pattern: pattern.Name,
+				File:    file,
+				Version: version.VersionName(),
+				Drift:   d,
+			}); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,68 @@
+package idgen
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// An id is composed of, from the most significant bit:
+//
+//	1 bit unused, so ids are always positive
+//	41 bits milliseconds since the epoch, which lasts for 69 years
+//	10 bits node id, up to 1024 processes generating ids at the same time
+//	12 bits sequence, up to 4096 ids per millisecond per node
+const (
+	nodeBits     = 10
+	sequenceBits = 12
+
+	MaxNodeID   = 1<<nodeBits - 1
+	maxSequence = 1<<sequenceBits - 1
+)
+
+var epoch = time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// Generator generates unique and roughly time ordered ids.
+// It is safe for concurrent use.
+type Generator struct {
+	mu       sync.Mutex
+	node     int64
+	lastTime int64
+	sequence int64
+	now      func() time.Time
+}
+
+func NewGenerator(node int64) (*Generator, error) {
+	if node < 0 || node > MaxNodeID {
+		return nil, fmt.Errorf("node id must be between 0 and %d", MaxNodeID)
+	}
+	return &Generator{node: node, now: time.Now}, nil
+}
+
+func (g *Generator) elapsed() int64 {
+	return g.now().Sub(epoch).Nanoseconds() / int64(time.Millisecond)
+}
+
+func (g *Generator) NextID() int64 {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	t := g.elapsed()
+	// never go back in time even if the clock does
+	if t < g.lastTime {
+		t = g.lastTime
+	}
+
+	if t == g.lastTime {
+		g.sequence = (g.sequence + 1) & maxSequence
+		if g.sequence == 0 {
+			// the sequence of this millisecond is exhausted, borrow the next one
+			t++
+		}
+	} else {
+		g.sequence = 0
+	}
+	g.lastTime = t
+
+	return t<<(nodeBits+sequenceBits) | g.node<<sequenceBits | g.sequence
+}
@@ -0,0 +1,74 @@
+package idgen
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewGenerator(t *testing.T) {
+	_, err := NewGenerator(-1)
+	assert.Error(t, err)
+	_, err = NewGenerator(MaxNodeID + 1)
+	assert.Error(t, err)
+	_, err = NewGenerator(MaxNodeID)
+	assert.NoError(t, err)
+}
+
+func TestNextIDUniqueAcrossNodes(t *testing.T) {
+	const nodes = 4
+	const workers = 8
+	const idsPerWorker = 10000
+
+	var mu sync.Mutex
+	seen := make(map[int64]bool, nodes*workers*idsPerWorker)
+
+	var wg sync.WaitGroup
+	for n := 0; n < nodes; n++ {
+		g, err := NewGenerator(int64(n))
+		assert.NoError(t, err)
+
+		for w := 0; w < workers; w++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				ids := make([]int64, 0, idsPerWorker)
+				for i := 0; i < idsPerWorker; i++ {
+					ids = append(ids, g.NextID())
+				}
+
+				mu.Lock()
+				defer mu.Unlock()
+				for _, id := range ids {
+					assert.True(t, id > 0)
+					assert.False(t, seen[id], "duplicated id %d", id)
+					seen[id] = true
+				}
+			}()
+		}
+	}
+	wg.Wait()
+
+	assert.Len(t, seen, nodes*workers*idsPerWorker)
+}
+
+func TestNextIDWithStoppedClock(t *testing.T) {
+	g, err := NewGenerator(1)
+	assert.NoError(t, err)
+
+	now := time.Now()
+	g.now = func() time.Time { return now }
+
+	last := int64(0)
+	for i := 0; i < 3*(maxSequence+1); i++ {
+		id := g.NextID()
+		assert.True(t, id > last)
+		last = id
+	}
+
+	// the clock goes backwards
+	g.now = func() time.Time { return now.Add(-time.Second) }
+	assert.True(t, g.NextID() > last)
+}
@@ -1,29 +1,32 @@
 package main
 
 import (
-	"encoding/json"
+	"context"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
-	"time"
+	"reflect"
+	"strconv"
 
 	"github.com/getsentry/sentry-go"
 	"github.com/jinzhu/gorm"
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/afero"
-	"github.com/t-tiger/gorm-bulk-insert"
 
+	"github.com/bitmark-inc/datapod/data-parser/idgen"
 	"github.com/bitmark-inc/datapod/data-parser/schema/facebook"
+	"github.com/bitmark-inc/datapod/data-parser/schema/instagram"
 	"github.com/bitmark-inc/datapod/data-parser/storage"
 )
 
-var patterns = []facebook.Pattern{
-	facebook.FriendsPattern,
-	facebook.PostsPattern,
-	facebook.ReactionsPattern,
-	facebook.CommentsPattern,
-	facebook.MediaPattern,
-	facebook.FilesPattern,
+// registryOf returns the registry of the handlers of the archive by its layout, an archive is
+// from Facebook unless it is found to be an Instagram export.
+func registryOf(archive *storage.ZipFs) *facebook.Registry {
+	if instagram.IsArchive(archive.Names()) {
+		return instagram.Handlers
+	}
+	return facebook.Handlers
 }
 
 func init() {
@@ -36,7 +39,7 @@ func init() {
 	})
 }
 
-func handle(db *gorm.DB, s3Bucket, workingDir string, task *storage.Task, parseTime time.Time) error {
+func handle(ctx context.Context, db *gorm.DB, store storage.ObjectStore, ids facebook.IDGenerator, workingDir string, task *storage.Task, strict bool) error {
 	contextLogger := log.WithFields(log.Fields{"task_id": task.ID})
 	contextLogger.Info("task started")
 
@@ -44,16 +47,12 @@ func handle(db *gorm.DB, s3Bucket, workingDir string, task *storage.Task, parseT
 	// <data-owner> /
 	//		archive/
 	//			<archive-file-name>.zip
-	// 		data/
-	// 			about_you/
-	// 			ads_and_businesses/
-	// 			and more...
+	// the files in the archive are read in place
 	dataOwner := task.Archive.DataOwnerID
 	dataOwnerDir := filepath.Join(workingDir, dataOwner)
 	archiveDir := filepath.Join(dataOwnerDir, "archive")
 	archiveName := filepath.Base(task.Archive.File)
 	archivePath := filepath.Join(archiveDir, archiveName)
-	dataDir := filepath.Join(dataOwnerDir, "data")
 
 	fs := afero.NewOsFs()
 	file, err := storage.CreateFile(fs, archivePath)
@@ -64,152 +63,282 @@ func handle(db *gorm.DB, s3Bucket, workingDir string, task *storage.Task, parseT
 	defer file.Close()
 	defer fs.RemoveAll(dataOwnerDir)
 
-	if err := storage.DownloadArchiveFromS3(s3Bucket, task.Archive.File, file); err != nil {
+	if err := storage.DownloadArchive(store, task.Archive.File, file); err != nil {
 		sentry.CaptureException(err)
 		return err
 	}
 	contextLogger.Info("archive downloaded")
 
-	ts := parseTime.UnixNano() / int64(time.Millisecond) // in milliseconds
-	postID := int(ts) * 1000000
-	postMediaID := int(ts) * 1000000
-	placeID := int(ts) * 1000000
-	tagID := int(ts) * 1000000
+	// all rows of a task are committed at once, so a failed task leaves no partial data behind
+	tx := db.Begin()
+	if err := tx.Error; err != nil {
+		sentry.CaptureException(err)
+		return err
+	}
+	tracker := &taskTracker{db: db, task: task}
+	// drifts are kept even if the task fails, so they are persisted outside of the transaction
+	if err := parseTask(ctx, &gormSink{tx}, &gormSink{db}, store, ids, archivePath, task, strict, tracker, contextLogger); err != nil {
+		task.FailedPattern = tracker.Current()
+		tx.Rollback()
+		contextLogger.Info("task rolled back")
+		return err
+	}
+	if err := tx.Commit().Error; err != nil {
+		sentry.CaptureException(err)
+		return err
+	}
 
-	for _, pattern := range patterns {
-		contextLogger.WithField("type", pattern.Name).Info("parsing and inserting records into db")
+	contextLogger.Info("task finished")
+	return nil
+}
 
-		if err := storage.ExtractArchive(archivePath, pattern.Location, dataDir); err != nil {
+// parseTask parses the archive of the task at archivePath. If it fails, the media uploaded by this run are
+// deleted, while those of the previous runs of the archive are kept.
+func parseTask(ctx context.Context, s, driftSink recordSink, store storage.ObjectStore, ids facebook.IDGenerator, archivePath string, task *storage.Task, strict bool, tracker progressTracker, contextLogger *log.Entry) error {
+	dataOwner := task.Archive.DataOwnerID
+	runStore, err := storage.NewRunStore(store, mediaKeyPrefix(dataOwner, task.Archive.ID)+"/")
+	if err != nil {
+		sentry.CaptureException(err)
+		return err
+	}
+	if err := parseArchive(ctx, s, driftSink, runStore, ids, archivePath, dataOwner, task.Archive.ID, parseOptions{patternNames: task.PatternNames(), strict: strict}, tracker, contextLogger); err != nil {
+		if err := runStore.Rollback(); err != nil {
 			sentry.CaptureException(err)
+		}
+		return err
+	}
+	return nil
+}
+
+// mediaKeyPrefix is the object key prefix of the media files of an archive
+func mediaKeyPrefix(dataOwner, archiveID string) string {
+	return fmt.Sprintf("%s/fb_archives/%s", dataOwner, archiveID)
+}
+
+// parseOptions tell which patterns of an archive are parsed and how.
+type parseOptions struct {
+	// patternNames are the patterns to parse, all patterns are parsed if it is empty
+	patternNames []string
+	// strict parsing fails on the first invalid record instead of quarantining it
+	strict bool
+}
+
+// parseArchive reads the archive pattern by pattern and sends the parsed rows to the sink.
+// Media files are streamed from the archive to the store. The drifts of the files from their
+// schemas are sent to driftSink, even if parsing fails since a failure may be caused by a format change.
+func parseArchive(ctx context.Context, s, driftSink recordSink, store storage.ObjectStore, ids facebook.IDGenerator, archivePath, dataOwner, archiveID string, options parseOptions, tracker progressTracker, contextLogger *log.Entry) error {
+	fs, err := storage.OpenZipFs(archivePath)
+	if err != nil {
+		sentry.CaptureException(err)
+		return err
+	}
+	defer fs.Close()
+
+	sink := &countingSink{recordSink: s}
+	parseCtx := facebook.NewParseContext(ids, dataOwner, archiveID, sink, store, mediaKeyPrefix(dataOwner, archiveID))
+	parseCtx.Strict = options.strict
+	// quarantined records are not counted as parsed rows
+	parseCtx.QuarantineSink = s
+
+	registry := registryOf(fs)
+	handlers, err := registry.Handlers(options.patternNames...)
+	if err != nil {
+		sentry.CaptureException(err)
+		return err
+	}
+	if err := registry.CheckSubset(handlers); err != nil {
+		sentry.CaptureException(err)
+		return err
+	}
+
+	// re-parsing an archive replaces the rows of the previous run
+	if err := sink.DeleteArchive(dataOwner, archiveID, deletionOrder(handlers)); err != nil {
+		sentry.CaptureException(err)
+		return err
+	}
+	if err := sink.DeletePatterns(dataOwner, archiveID, patternNamesOf(handlers), []interface{}{facebook.QuarantineORM{}}); err != nil {
+		sentry.CaptureException(err)
+		return err
+	}
+	defer func() {
+		// the drifts are only for noticing format changes, so failing to persist them doesn't fail parsing
+		if err := persistDrifts(driftSink, parseCtx, handlers, contextLogger); err != nil {
+			contextLogger.WithError(err).Error("failed to persist schema drifts")
+			sentry.CaptureException(err)
+		}
+	}()
+
+	for _, h := range handlers {
+		if err := ctx.Err(); err != nil {
 			return err
 		}
+		pattern := h.Pattern()
+		contextLogger.WithField("type", pattern.Name).Info("parsing and inserting records into db")
+		tracker.PatternStarted(pattern.Name)
+		sink.rows = 0
+		quarantined := parseCtx.QuarantinedCount
 
-		subDir := filepath.Join(dataDir, pattern.Location)
-		if pattern.Name == "media" || pattern.Name == "files" {
-			if err := storage.UploadDirToS3(s3Bucket, fmt.Sprintf("%s/fb_archives/%s", dataOwner, task.Archive.ID), subDir); err != nil {
-				sentry.CaptureException(err)
-				continue
-			}
-		} else {
+		subDir := pattern.Location
+		// patterns without regexps have only media files to be uploaded
+		if pattern.Regexp != nil {
 			files, err := pattern.SelectFiles(fs, subDir)
 			if err != nil {
 				sentry.CaptureException(err)
 				return err
 			}
 			for _, file := range files {
-				data, err := afero.ReadFile(fs, file)
-				if err != nil {
+				if err := ctx.Err(); err != nil {
+					return err
+				}
+				fileQuarantined := parseCtx.QuarantinedCount
+				if err := parseFile(fs, parseCtx, h, file); err != nil {
 					sentry.CaptureException(err)
 					return err
 				}
-
-				if err := pattern.Validate(data); err != nil {
+				if err := parseCtx.FlushQuarantine(); err != nil {
 					sentry.CaptureException(err)
 					return err
 				}
-
-				switch pattern.Name {
-				case "friends":
-					rawFriends := &facebook.RawFriends{}
-					json.Unmarshal(data, &rawFriends)
-					if err := gormbulk.BulkInsert(db, rawFriends.ORM(ts, dataOwner), 1000); err != nil {
-						// friends must exist for inserting tags
-						// stop processing if it fails to insert friends
-						sentry.CaptureException(err)
-						return err
-					}
-				case "posts":
-					rawPosts := facebook.RawPosts{Items: make([]*facebook.RawPost, 0)}
-					json.Unmarshal(data, &rawPosts.Items)
-					posts, complexPosts := rawPosts.ORM(dataOwner, task.Archive.ID, &postID, &postMediaID, &placeID, &tagID)
-					if err := gormbulk.BulkInsert(db, posts, 1000); err != nil {
-						sentry.CaptureException(err)
-						continue
-					}
-					for _, p := range complexPosts {
-						if len(p.Tags) > 0 {
-							friends := make([]facebook.FriendORM, 0)
-							if err := db.Where("data_owner_id = ?", dataOwner).Find(&friends).Error; err != nil {
-								// friends must exist for inserting tags
-								// deal with the next post if it fails to find friends of this data owner
-								sentry.CaptureException(err)
-								continue
-							}
-
-							friendIDs := make(map[string]int)
-							for _, f := range friends {
-								friendIDs[f.FriendName] = f.PKID
-							}
-
-							// FIXME: non-friends couldn't be tagged
-							c := 0 // valid tag count
-							for i := range p.Tags {
-								friendID, ok := friendIDs[p.Tags[i].Name]
-								if ok {
-									p.Tags[i].FriendID = friendID
-									c++
-								}
-							}
-							p.Tags = p.Tags[:c]
-						}
-
-						if err := db.Create(&p).Error; err != nil {
-							sentry.CaptureException(err)
-							continue
-						}
-					}
-				case "comments":
-					rawComments := &facebook.RawComments{}
-					json.Unmarshal(data, &rawComments)
-					if err := gormbulk.BulkInsert(db, rawComments.ORM(ts, dataOwner), 1000); err != nil {
-						sentry.CaptureException(err)
-						continue
-					}
-				case "reactions":
-					rawReactions := &facebook.RawReactions{}
-					json.Unmarshal(data, &rawReactions)
-					if err := gormbulk.BulkInsert(db, rawReactions.ORM(ts, dataOwner), 1000); err != nil {
-						sentry.CaptureException(err)
-						continue
-					}
+				if n := parseCtx.QuarantinedCount - fileQuarantined; n > 0 {
+					contextLogger.WithFields(log.Fields{"type": pattern.Name, "file": file, "records": n}).Warn("invalid records quarantined")
 				}
 			}
 		}
 
-		fs.RemoveAll(subDir)
+		if err := h.Finish(parseCtx, fs, subDir); err != nil {
+			sentry.CaptureException(err)
+			return err
+		}
+
+		tracker.PatternFinished(pattern.Name, sink.rows, parseCtx.QuarantinedCount-quarantined)
 	}
 
-	contextLogger.Info("task finished")
 	return nil
 }
 
-func main() {
-	postgresURI := os.Getenv("POSTGRES_URI")
-	s3Bucket := os.Getenv("AWS_S3_BUCKET")
-	workingDir := os.Getenv("DATA_PARSER_WORKING_DIR")
+// persistDrifts replaces the drifts of the patterns of the handlers with those found by parsing,
+// so that format changes of the exports are noticed.
+func persistDrifts(s recordSink, parseCtx *facebook.ParseContext, handlers []facebook.Handler, contextLogger *log.Entry) error {
+	if err := s.DeletePatterns(parseCtx.DataOwner, parseCtx.ArchiveID, patternNamesOf(handlers), []interface{}{facebook.DriftORM{}}); err != nil {
+		return err
+	}
 
-	db := storage.NewPostgresORMDB(postgresURI)
+	drifts := parseCtx.Drifts.ORM(parseCtx.DataOwner, parseCtx.ArchiveID)
+	if len(drifts) == 0 {
+		return nil
+	}
+	contextLogger.WithField("drifts", len(drifts)).Warn("files drift from their schemas")
+	return s.BulkInsert(drifts)
+}
 
-	for {
-		task, err := storage.GetNextRunningTask(db)
-		if err != nil {
-			sentry.CaptureException(err)
-		}
+// parseFile upgrades, validates and decodes a file, then persists the rows transformed from it.
+// Files of the handlers that stream are decoded item by item instead. Invalid records
+// are left in the quarantine of the parse context unless parsing is strict.
+func parseFile(fs afero.Fs, parseCtx *facebook.ParseContext, h facebook.Handler, file string) error {
+	if s, ok := h.(facebook.Streamer); ok {
+		return s.Stream(parseCtx, fs, file)
+	}
 
-		if task == nil {
-			time.Sleep(time.Minute)
-			continue
-		}
+	data, err := afero.ReadFile(fs, file)
+	if err != nil {
+		return err
+	}
 
-		err = handle(db, s3Bucket, workingDir, task, time.Now())
+	data, err = parseCtx.PrepareFile(h, file, data)
+	if err != nil || data == nil {
+		return err
+	}
 
-		status := storage.TaskStatusFinished
-		if err != nil {
-			status = storage.TaskStatusFailed
+	v, err := h.Decode(data)
+	if err != nil {
+		return err
+	}
+
+	rows, err := h.Transform(parseCtx, file, v)
+	if err != nil {
+		return err
+	}
+
+	return h.Persist(parseCtx, rows)
+}
+
+func patternNamesOf(handlers []facebook.Handler) []string {
+	names := make([]string, 0, len(handlers))
+	for _, h := range handlers {
+		names = append(names, h.Pattern().Name)
+	}
+	return names
+}
+
+// deletionOrder returns the models of the handlers in the order that their rows can be deleted.
+// Handlers run after the handlers whose rows they refer to, so the models are reversed.
+func deletionOrder(handlers []facebook.Handler) []interface{} {
+	models := make([]interface{}, 0)
+	seen := make(map[reflect.Type]bool)
+	for i := len(handlers) - 1; i >= 0; i-- {
+		handlerModels := handlers[i].Models()
+		for j := len(handlerModels) - 1; j >= 0; j-- {
+			t := reflect.TypeOf(handlerModels[j])
+			if !seen[t] {
+				seen[t] = true
+				models = append(models, handlerModels[j])
+			}
 		}
+	}
+	return models
+}
 
-		if err := storage.UpdateTaskStatus(db, task, status); err != nil {
-			sentry.CaptureException(err)
+// newObjectStore returns the store configured by DATA_PARSER_OBJECT_STORE.
+// It is S3 by default, "local" keeps objects under DATA_PARSER_OBJECT_STORE_DIR.
+func newObjectStore() (storage.ObjectStore, error) {
+	switch os.Getenv("DATA_PARSER_OBJECT_STORE") {
+	case "", "s3":
+		forcePathStyle, _ := strconv.ParseBool(os.Getenv("AWS_S3_FORCE_PATH_STYLE"))
+		return storage.NewS3ObjectStore(storage.S3Config{
+			Bucket:         os.Getenv("AWS_S3_BUCKET"),
+			Region:         os.Getenv("AWS_REGION"),
+			Endpoint:       os.Getenv("AWS_S3_ENDPOINT"),
+			ForcePathStyle: forcePathStyle,
+		})
+	case "local":
+		return storage.NewLocalObjectStore(os.Getenv("DATA_PARSER_OBJECT_STORE_DIR")), nil
+	default:
+		return nil, fmt.Errorf("unknown object store: %s", os.Getenv("DATA_PARSER_OBJECT_STORE"))
+	}
+}
+
+// newIDGenerator returns an id generator for the node set by DATA_PARSER_NODE_ID.
+// Each running parser must have its own node id, it is required since random ones would collide.
+func newIDGenerator() (*idgen.Generator, error) {
+	s := os.Getenv("DATA_PARSER_NODE_ID")
+	if s == "" {
+		return nil, errors.New("DATA_PARSER_NODE_ID is not set")
+	}
+	nodeID, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid node id: %s", err)
+	}
+	return idgen.NewGenerator(nodeID)
+}
+
+func main() {
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "worker":
+		case "parse":
+			if err := runParse(os.Args[2:]); err != nil {
+				log.Fatal(err)
+			}
+			return
+		case "drift":
+			if err := runDrift(os.Args[2:]); err != nil {
+				log.Fatal(err)
+			}
+			return
+		default:
+			log.Fatalf("unknown command: %s", os.Args[1])
 		}
 	}
+
+	runWorker()
 }
@@ -0,0 +1,277 @@
+package main
+
+import (
+	"archive/zip"
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/bitmark-inc/datapod/data-parser/idgen"
+	"github.com/bitmark-inc/datapod/data-parser/schema/facebook"
+	"github.com/bitmark-inc/datapod/data-parser/storage"
+)
+
+// memorySink keeps the rows in memory, rows of a model are deleted by their archive as in the database.
+type memorySink struct {
+	rows []interface{}
+}
+
+func (s *memorySink) BulkInsert(rows []interface{}) error {
+	s.rows = append(s.rows, rows...)
+	return nil
+}
+
+func (s *memorySink) Create(row interface{}) error {
+	s.rows = append(s.rows, reflect.Indirect(reflect.ValueOf(row)).Interface())
+	return nil
+}
+
+func (s *memorySink) FriendIDs(dataOwner, archiveID string) (map[string]int, error) {
+	friendIDs := make(map[string]int)
+	for i, row := range s.rows {
+		if f, ok := row.(facebook.FriendORM); ok && f.DataOwnerID == dataOwner && f.ArchiveID == archiveID {
+			friendIDs[f.FriendName] = i + 1
+		}
+	}
+	return friendIDs, nil
+}
+
+func (s *memorySink) DeleteArchive(dataOwner, archiveID string, models []interface{}) error {
+	deleted := make(map[reflect.Type]bool)
+	for _, m := range models {
+		deleted[reflect.TypeOf(m)] = true
+	}
+
+	rows := make([]interface{}, 0, len(s.rows))
+	for _, row := range s.rows {
+		v := reflect.ValueOf(row)
+		if deleted[v.Type()] && v.FieldByName("DataOwnerID").String() == dataOwner && v.FieldByName("ArchiveID").String() == archiveID {
+			continue
+		}
+		rows = append(rows, row)
+	}
+	s.rows = rows
+	return nil
+}
+
+func (s *memorySink) DeletePatterns(dataOwner, archiveID string, patterns []string, models []interface{}) error {
+	deleted := make(map[reflect.Type]bool)
+	for _, m := range models {
+		deleted[reflect.TypeOf(m)] = true
+	}
+
+	rows := make([]interface{}, 0, len(s.rows))
+	for _, row := range s.rows {
+		v := reflect.ValueOf(row)
+		if deleted[v.Type()] && v.FieldByName("DataOwnerID").String() == dataOwner && v.FieldByName("ArchiveID").String() == archiveID && contains(patterns, v.FieldByName("Pattern").String()) {
+			continue
+		}
+		rows = append(rows, row)
+	}
+	s.rows = rows
+	return nil
+}
+
+func contains(names []string, name string) bool {
+	for _, n := range names {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+// count returns the number of rows of the model.
+func (s *memorySink) count(model interface{}) int {
+	n := 0
+	for _, row := range s.rows {
+		if reflect.TypeOf(row) == reflect.TypeOf(model) {
+			n++
+		}
+	}
+	return n
+}
+
+func writeArchive(t *testing.T, path string, files map[string]string) {
+	f, err := os.Create(path)
+	assert.NoError(t, err)
+	defer f.Close()
+
+	w := zip.NewWriter(f)
+	for name, content := range files {
+		entry, err := w.Create(name)
+		assert.NoError(t, err)
+		_, err = entry.Write([]byte(content))
+		assert.NoError(t, err)
+	}
+	assert.NoError(t, w.Close())
+}
+
+// testArchive writes an archive of posts, locations and follows, and returns its path.
+func testArchive(t *testing.T, dir string) string {
+	archivePath := filepath.Join(dir, "archive.zip")
+	writeArchive(t, archivePath, map[string]string{
+		"posts/your_posts_1.json": `[
+			{"timestamp": 1578201080, "data": [{"post": "hello"}]},
+			{"timestamp": 1578201090, "data": [{"post": "world"}]}
+		]`,
+		"location_history/your_location_history.json": `{"location_history": [
+			{"name": "Home", "coordinate": {"latitude": 3, "longitude": 4}, "creation_timestamp": 1578201100}
+		]}`,
+		"following_and_followers/followers.json": `{"followers": [{"name": "Alice"}]}`,
+		"following_and_followers/following.json": `{"following": [{"name": "Bob", "timestamp": 1578201110}]}`,
+	})
+	return archivePath
+}
+
+func parseTestArchive(sink *memorySink, dir, archivePath string, patternNames ...string) error {
+	ids, err := idgen.NewGenerator(1)
+	if err != nil {
+		return err
+	}
+	store := storage.NewLocalObjectStore(filepath.Join(dir, "store"))
+	contextLogger := log.WithField("archive", archivePath)
+	return parseArchive(context.Background(), sink, sink, store, ids, archivePath, "owner", "archive", parseOptions{patternNames: patternNames}, &logTracker{contextLogger}, contextLogger)
+}
+
+func TestParseSubsetOfArchive(t *testing.T) {
+	dir, err := ioutil.TempDir("", "parse-subset")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	archivePath := testArchive(t, dir)
+
+	sink := &memorySink{}
+	assert.NoError(t, parseTestArchive(sink, dir, archivePath))
+	assert.Equal(t, 2, sink.count(facebook.Post{}))
+	assert.Equal(t, 1, sink.count(facebook.Place{}))
+	assert.Equal(t, 2, sink.count(facebook.FollowORM{}))
+
+	// places of the location history would be lost by re-parsing posts alone
+	err = parseTestArchive(sink, dir, archivePath, "posts")
+	assert.EqualError(t, err, "patterns posts must be parsed along with friends,friendship_events,location_history,primary_location")
+	assert.Equal(t, 1, sink.count(facebook.Place{}))
+
+	assert.Error(t, parseTestArchive(sink, dir, archivePath, "followers"))
+	assert.Equal(t, 2, sink.count(facebook.FollowORM{}))
+
+	// re-parsing the patterns sharing a table replaces their rows only
+	assert.NoError(t, parseTestArchive(sink, dir, archivePath, "followers", "following", "followed_pages"))
+	assert.Equal(t, 2, sink.count(facebook.FollowORM{}))
+	assert.Equal(t, 2, sink.count(facebook.Post{}))
+	assert.Equal(t, 1, sink.count(facebook.Place{}))
+
+	assert.NoError(t, parseTestArchive(sink, dir, archivePath, "friends", "friendship_events", "posts", "location_history", "primary_location"))
+	assert.Equal(t, 2, sink.count(facebook.Post{}))
+	assert.Equal(t, 1, sink.count(facebook.Place{}))
+	assert.Equal(t, 2, sink.count(facebook.FollowORM{}))
+}
+
+func TestDriftsOfFailedParse(t *testing.T) {
+	dir, err := ioutil.TempDir("", "parse-drift")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	archivePath := filepath.Join(dir, "archive.zip")
+	writeArchive(t, archivePath, map[string]string{
+		"following_and_followers/followers.json": `{"followers": [{"name": "Alice", "uri": "https://facebook.com/alice"}]}`,
+	})
+
+	ids, err := idgen.NewGenerator(1)
+	assert.NoError(t, err)
+	sink, driftSink := &memorySink{}, &memorySink{}
+	store := storage.NewLocalObjectStore(filepath.Join(dir, "store"))
+	contextLogger := log.WithField("archive", archivePath)
+
+	// the unknown field fails strict parsing, while the drift is kept
+	err = parseArchive(context.Background(), sink, driftSink, store, ids, archivePath, "owner", "archive", parseOptions{strict: true}, &logTracker{contextLogger}, contextLogger)
+	assert.Error(t, err)
+	if assert.Len(t, driftSink.rows, 1) {
+		drift := driftSink.rows[0].(facebook.DriftORM)
+		assert.Equal(t, "followers", drift.Pattern)
+		assert.Equal(t, "followers[].uri", drift.Path)
+	}
+
+	// the drifts are replaced by parsing again
+	err = parseArchive(context.Background(), sink, driftSink, store, ids, archivePath, "owner", "archive", parseOptions{strict: true}, &logTracker{contextLogger}, contextLogger)
+	assert.Error(t, err)
+	assert.Len(t, driftSink.rows, 1)
+}
+
+func TestQuarantineOfSubsetOfArchive(t *testing.T) {
+	dir, err := ioutil.TempDir("", "parse-quarantine")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	archivePath := filepath.Join(dir, "archive.zip")
+	writeArchive(t, archivePath, map[string]string{
+		"ads_and_businesses/ads_interests.json":  `{"topics": ["Go", 1]}`,
+		"following_and_followers/followers.json": `{"followers": [{"name": "Alice"}, {}]}`,
+	})
+
+	sink := &memorySink{}
+	assert.NoError(t, parseTestArchive(sink, dir, archivePath))
+	assert.Equal(t, 2, sink.count(facebook.QuarantineORM{}))
+
+	// the quarantined records of the other patterns are kept
+	assert.NoError(t, parseTestArchive(sink, dir, archivePath, "ad_interests"))
+	assert.Equal(t, 2, sink.count(facebook.QuarantineORM{}))
+}
+
+func TestParseTaskRollsBackMedia(t *testing.T) {
+	dir, err := ioutil.TempDir("", "parse-task")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	archivePath := filepath.Join(dir, "archive.zip")
+	writeArchive(t, archivePath, map[string]string{
+		"stories/archived_stories.json":         `{"archived_stories": []}`,
+		"stories/media/new.jpg":                 "new",
+		"ads_and_businesses/ads_interests.json": `{"topics": "Travel"}`,
+	})
+
+	store := storage.NewLocalObjectStore(filepath.Join(dir, "store"))
+	prefix := mediaKeyPrefix("owner", "archive")
+	assert.NoError(t, store.Put(prefix+"/stories/media/old.jpg", strings.NewReader("old")))
+
+	ids, err := idgen.NewGenerator(1)
+	assert.NoError(t, err)
+	task := &storage.Task{ID: "task", Archive: storage.Archive{ID: "archive", DataOwnerID: "owner"}}
+	contextLogger := log.WithField("archive", archivePath)
+
+	// the invalid ad interests fail the task after the media of stories are uploaded
+	sink := &memorySink{}
+	assert.Error(t, parseTask(context.Background(), sink, sink, store, ids, archivePath, task, true, &logTracker{contextLogger}, contextLogger))
+	keys, err := store.List(prefix + "/")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{prefix + "/stories/media/old.jpg"}, keys)
+
+	// they are quarantined otherwise
+	assert.NoError(t, parseTask(context.Background(), sink, sink, store, ids, archivePath, task, false, &logTracker{contextLogger}, contextLogger))
+	keys, err = store.List(prefix + "/")
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []string{prefix + "/stories/media/old.jpg", prefix + "/stories/media/new.jpg"}, keys)
+}
+
+func TestNewIDGenerator(t *testing.T) {
+	nodeID := os.Getenv("DATA_PARSER_NODE_ID")
+	defer os.Setenv("DATA_PARSER_NODE_ID", nodeID)
+
+	os.Unsetenv("DATA_PARSER_NODE_ID")
+	_, err := newIDGenerator()
+	assert.EqualError(t, err, "DATA_PARSER_NODE_ID is not set")
+
+	os.Setenv("DATA_PARSER_NODE_ID", "1")
+	_, err = newIDGenerator()
+	assert.NoError(t, err)
+
+	os.Setenv("DATA_PARSER_NODE_ID", "1024")
+	_, err = newIDGenerator()
+	assert.Error(t, err)
+}
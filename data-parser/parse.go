@@ -0,0 +1,68 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/bitmark-inc/datapod/data-parser/idgen"
+	"github.com/bitmark-inc/datapod/data-parser/storage"
+)
+
+// runParse parses a local archive without Postgres or S3.
+// Rows are written as JSON Lines to the output dir and media files are copied into <out>/objects.
+func runParse(args []string) error {
+	flags := flag.NewFlagSet("parse", flag.ExitOnError)
+	archivePath := flags.String("archive", "", "path to the archive zip file")
+	dataOwner := flags.String("owner", "", "data owner id of the archive")
+	archiveID := flags.String("archive-id", "", "archive id used in the media keys, defaults to the archive file name")
+	outDir := flags.String("out", "out", "output directory")
+	format := flags.String("format", "jsonl", "output format, only jsonl is supported")
+	patterns := flags.String("patterns", "", "comma-separated names of the patterns to parse, defaults to all")
+	strict := flags.Bool("strict", false, "fail on the first invalid record instead of quarantining it")
+	flags.Parse(args)
+
+	if *archivePath == "" || *dataOwner == "" {
+		flags.Usage()
+		return errors.New("both archive and owner are required")
+	}
+	if *format != "jsonl" {
+		return fmt.Errorf("unsupported output format: %s", *format)
+	}
+	if *archiveID == "" {
+		name := filepath.Base(*archivePath)
+		*archiveID = strings.TrimSuffix(name, filepath.Ext(name))
+	}
+
+	sink, err := newJSONLSink(*outDir)
+	if err != nil {
+		return err
+	}
+	defer sink.Close()
+
+	store := storage.NewLocalObjectStore(filepath.Join(*outDir, "objects"))
+
+	// the command generates ids alone, so the node id is optional
+	ids, err := idgen.NewGenerator(0)
+	if os.Getenv("DATA_PARSER_NODE_ID") != "" {
+		ids, err = newIDGenerator()
+	}
+	if err != nil {
+		return err
+	}
+
+	contextLogger := log.WithFields(log.Fields{"archive": *archivePath})
+	contextLogger.Info("parsing started")
+	if err := parseArchive(context.Background(), sink, sink, store, ids, *archivePath, *dataOwner, *archiveID, parseOptions{patternNames: storage.SplitPatternNames(*patterns), strict: *strict}, &logTracker{contextLogger}, contextLogger); err != nil {
+		return err
+	}
+	contextLogger.Info("parsing finished")
+
+	return sink.Close()
+}
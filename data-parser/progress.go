@@ -0,0 +1,74 @@
+package main
+
+import (
+	"time"
+
+	"github.com/getsentry/sentry-go"
+	"github.com/jinzhu/gorm"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/bitmark-inc/datapod/data-parser/storage"
+)
+
+// progressTracker is notified as the patterns of an archive are parsed.
+type progressTracker interface {
+	PatternStarted(name string)
+	// PatternFinished is called with the number of rows parsed and of invalid records quarantined.
+	PatternFinished(name string, rows, quarantined int)
+}
+
+// taskTracker keeps the progress on the task row, so that what happened
+// to an archive can be told from the database alone.
+type taskTracker struct {
+	db   *gorm.DB
+	task *storage.Task
+}
+
+func (t *taskTracker) PatternStarted(name string) {
+	t.task.Progress = append(t.task.Progress, &storage.PatternProgress{
+		Name:      name,
+		StartedAt: time.Now(),
+	})
+	t.save()
+}
+
+func (t *taskTracker) PatternFinished(name string, rows, quarantined int) {
+	if len(t.task.Progress) == 0 {
+		return
+	}
+	now := time.Now()
+	p := t.task.Progress[len(t.task.Progress)-1]
+	p.Rows = rows
+	p.Quarantined = quarantined
+	p.FinishedAt = &now
+	t.save()
+}
+
+// Current returns the pattern being parsed, or an empty string if there is none.
+func (t *taskTracker) Current() string {
+	if len(t.task.Progress) == 0 {
+		return ""
+	}
+	p := t.task.Progress[len(t.task.Progress)-1]
+	if p.FinishedAt != nil {
+		return ""
+	}
+	return p.Name
+}
+
+func (t *taskTracker) save() {
+	// progress is not essential for parsing, so a failure doesn't stop the task
+	if err := storage.UpdateTaskProgress(t.db, t.task); err != nil {
+		sentry.CaptureException(err)
+	}
+}
+
+type logTracker struct {
+	logger *log.Entry
+}
+
+func (t *logTracker) PatternStarted(name string) {}
+
+func (t *logTracker) PatternFinished(name string, rows, quarantined int) {
+	t.logger.WithFields(log.Fields{"type": name, "rows": rows, "quarantined": quarantined}).Info("pattern finished")
+}
@@ -0,0 +1,171 @@
+package facebook
+
+import (
+	"time"
+
+	"github.com/xeipuuv/gojsonschema"
+)
+
+type RawAdvertiserContactLists struct {
+	CustomAudiences []MojibakeString `json:"custom_audiences" jsonschema:"required"`
+}
+
+type RawAdInteractions struct {
+	History []*AdInteraction `json:"history" jsonschema:"required"`
+}
+
+type AdInteraction struct {
+	Title     MojibakeString `json:"title" jsonschema:"required"`
+	Action    MojibakeString `json:"action" jsonschema:"required"`
+	Timestamp int            `json:"timestamp" jsonschema:"required"`
+}
+
+type RawAdInterests struct {
+	Topics []MojibakeString `json:"topics" jsonschema:"required"`
+}
+
+type RawOffFacebookActivities struct {
+	OffFacebookActivity []*OffFacebookActivity `json:"off_facebook_activity" jsonschema:"required"`
+}
+
+type OffFacebookActivity struct {
+	Name   MojibakeString              `json:"name" jsonschema:"required"`
+	Events []*OffFacebookActivityEvent `json:"events" jsonschema:"required"`
+}
+
+type OffFacebookActivityEvent struct {
+	ID        int64  `json:"id" jsonschema:"required"`
+	Type      string `json:"type" jsonschema:"required"`
+	Timestamp int    `json:"timestamp" jsonschema:"required"`
+}
+
+func AdvertiserContactListSchemaLoader() *gojsonschema.Schema {
+	return SchemaLoaderOf(&RawAdvertiserContactLists{})
+}
+
+func AdInteractionSchemaLoader() *gojsonschema.Schema {
+	return SchemaLoaderOf(&RawAdInteractions{})
+}
+
+func AdInterestSchemaLoader() *gojsonschema.Schema {
+	return SchemaLoaderOf(&RawAdInterests{})
+}
+
+func OffFacebookActivitySchemaLoader() *gojsonschema.Schema {
+	return SchemaLoaderOf(&RawOffFacebookActivities{})
+}
+
+type AdvertiserContactListORM struct {
+	AdvertiserName string
+	DataOwnerID    string
+	ArchiveID      string
+}
+
+func (AdvertiserContactListORM) TableName() string {
+	return "ads_advertisercontactlist"
+}
+
+type AdInteractionORM struct {
+	InteractionID int64
+	Title         string
+	Action        string
+	Timestamp     int
+	Date          string
+	Weekday       int
+	DataOwnerID   string
+	ArchiveID     string
+}
+
+func (AdInteractionORM) TableName() string {
+	return "ads_interaction"
+}
+
+type AdInterestORM struct {
+	Topic       string
+	DataOwnerID string
+	ArchiveID   string
+}
+
+func (AdInterestORM) TableName() string {
+	return "ads_interest"
+}
+
+type OffFacebookActivityORM struct {
+	ActivityID  int64
+	AppName     string
+	EventID     int64
+	Type        string
+	Timestamp   int
+	Date        string
+	Weekday     int
+	DataOwnerID string
+	ArchiveID   string
+}
+
+func (OffFacebookActivityORM) TableName() string {
+	return "ads_offfacebookactivity"
+}
+
+func (r RawAdvertiserContactLists) ORM(owner, archiveID string) []interface{} {
+	result := make([]interface{}, 0)
+	for _, name := range r.CustomAudiences {
+		result = append(result, AdvertiserContactListORM{
+			AdvertiserName: string(name),
+			DataOwnerID:    owner,
+			ArchiveID:      archiveID,
+		})
+	}
+	return result
+}
+
+func (r RawAdInteractions) ORM(ids IDGenerator, owner, archiveID string) []interface{} {
+	result := make([]interface{}, 0)
+	for _, h := range r.History {
+		t := time.Unix(int64(h.Timestamp), 0)
+		result = append(result, AdInteractionORM{
+			InteractionID: ids.NextID(),
+			Title:         string(h.Title),
+			Action:        string(h.Action),
+			Timestamp:     h.Timestamp,
+			Date:          DateOfTime(t),
+			Weekday:       WeekdayOfTime(t),
+			DataOwnerID:   owner,
+			ArchiveID:     archiveID,
+		})
+	}
+	return result
+}
+
+func (r RawAdInterests) ORM(owner, archiveID string) []interface{} {
+	result := make([]interface{}, 0)
+	for _, topic := range r.Topics {
+		result = append(result, AdInterestORM{
+			Topic:       string(topic),
+			DataOwnerID: owner,
+			ArchiveID:   archiveID,
+		})
+	}
+	return result
+}
+
+// ORM returns an activity row for each event an app or website shared with Facebook.
+func (r RawOffFacebookActivities) ORM(ids IDGenerator, owner, archiveID string) []interface{} {
+	result := make([]interface{}, 0)
+	for _, a := range r.OffFacebookActivity {
+		for _, e := range a.Events {
+			t := time.Unix(int64(e.Timestamp), 0)
+			result = append(result, OffFacebookActivityORM{
+				ActivityID:  ids.NextID(),
+				AppName:     string(a.Name),
+				EventID:     e.ID,
+				Type:        e.Type,
+				Timestamp:   e.Timestamp,
+				Date:        DateOfTime(t),
+				Weekday:     WeekdayOfTime(t),
+				DataOwnerID: owner,
+				ArchiveID:   archiveID,
+			})
+		}
+	}
+	return result
+}
@@ -0,0 +1,104 @@
+package facebook
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAdsORM(t *testing.T) {
+	interacted := time.Unix(1578201080, 0)
+	shared := time.Unix(1578201090, 0)
+
+	cases := map[string]struct {
+		content string
+		orm     func(data []byte) []interface{}
+		rows    []interface{}
+	}{
+		"contact lists": {
+			`{"custom_audiences":["Shop A","CafÃ©"]}`,
+			func(data []byte) []interface{} {
+				var raw RawAdvertiserContactLists
+				assert.NoError(t, json.Unmarshal(data, &raw))
+				return raw.ORM("owner", "archive")
+			},
+			[]interface{}{
+				AdvertiserContactListORM{AdvertiserName: "Shop A", DataOwnerID: "owner", ArchiveID: "archive"},
+				AdvertiserContactListORM{AdvertiserName: "Café", DataOwnerID: "owner", ArchiveID: "archive"},
+			},
+		},
+		"interactions": {
+			`{"history":[{"title":"Shoes","action":"Clicked ad","timestamp":1578201080}]}`,
+			func(data []byte) []interface{} {
+				var raw RawAdInteractions
+				assert.NoError(t, json.Unmarshal(data, &raw))
+				return raw.ORM(&sequence{}, "owner", "archive")
+			},
+			[]interface{}{
+				AdInteractionORM{
+					InteractionID: 1,
+					Title:         "Shoes",
+					Action:        "Clicked ad",
+					Timestamp:     1578201080,
+					Date:          DateOfTime(interacted),
+					Weekday:       WeekdayOfTime(interacted),
+					DataOwnerID:   "owner",
+					ArchiveID:     "archive",
+				},
+			},
+		},
+		"interests": {
+			`{"topics":["Travel"]}`,
+			func(data []byte) []interface{} {
+				var raw RawAdInterests
+				assert.NoError(t, json.Unmarshal(data, &raw))
+				return raw.ORM("owner", "archive")
+			},
+			[]interface{}{
+				AdInterestORM{Topic: "Travel", DataOwnerID: "owner", ArchiveID: "archive"},
+			},
+		},
+		"off-facebook activities": {
+			`{"off_facebook_activity":[
+				{"name":"shop.example","events":[{"id":123,"type":"PURCHASE","timestamp":1578201090},{"id":123,"type":"PAGE_VIEW","timestamp":1578201090}]},
+				{"name":"app.example","events":[]}
+			]}`,
+			func(data []byte) []interface{} {
+				var raw RawOffFacebookActivities
+				assert.NoError(t, json.Unmarshal(data, &raw))
+				return raw.ORM(&sequence{}, "owner", "archive")
+			},
+			// a row for each event, none for an app without events
+			[]interface{}{
+				OffFacebookActivityORM{
+					ActivityID:  1,
+					AppName:     "shop.example",
+					EventID:     123,
+					Type:        "PURCHASE",
+					Timestamp:   1578201090,
+					Date:        DateOfTime(shared),
+					Weekday:     WeekdayOfTime(shared),
+					DataOwnerID: "owner",
+					ArchiveID:   "archive",
+				},
+				OffFacebookActivityORM{
+					ActivityID:  2,
+					AppName:     "shop.example",
+					EventID:     123,
+					Type:        "PAGE_VIEW",
+					Timestamp:   1578201090,
+					Date:        DateOfTime(shared),
+					Weekday:     WeekdayOfTime(shared),
+					DataOwnerID: "owner",
+					ArchiveID:   "archive",
+				},
+			},
+		},
+	}
+
+	for name, c := range cases {
+		assert.Equal(t, c.rows, c.orm([]byte(c.content)), name)
+	}
+}
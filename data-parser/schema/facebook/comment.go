@@ -3,7 +3,6 @@ package facebook
 import (
 	"time"
 
-	"github.com/alecthomas/jsonschema"
 	"github.com/xeipuuv/gojsonschema"
 )
 
@@ -30,16 +29,7 @@ type CommentData struct {
 }
 
 func CommentArraySchemaLoader() *gojsonschema.Schema {
-	reflector := jsonschema.Reflector{
-		AllowAdditionalProperties:  false,
-		ExpandedStruct:             true,
-		RequiredFromJSONSchemaTags: true,
-	}
-	s := reflector.Reflect(&RawComments{})
-	data, _ := s.MarshalJSON()
-	schemaLoader := gojsonschema.NewStringLoader(string(data))
-	schema, _ := gojsonschema.NewSchema(schemaLoader)
-	return schema
+	return SchemaLoaderOf(&RawComments{})
 }
 
 type CommentORM struct {
@@ -49,32 +39,43 @@ type CommentORM struct {
 	Comment     string
 	Date        string
 	Weekday     int
+	GroupID     *int64
 	DataOwnerID string
+	ArchiveID   string
 }
 
 func (CommentORM) TableName() string {
 	return "comments_comment"
 }
 
-func (c RawComments) ORM(parseTime int64, owner string) []interface{} {
-	idx := 0
+// ORM returns the comments, those in groups are linked to the groups in groupIDs.
+// The comments are preceded by the groups which aren't known yet.
+func (c RawComments) ORM(ids IDGenerator, owner, archiveID string, groupIDs GroupIDs) []interface{} {
+	groups := make([]interface{}, 0)
 	result := make([]interface{}, 0)
 	for _, c := range c.Comments {
 		t := time.Unix(int64(c.Timestamp), 0)
 		orm := CommentORM{
-			CommentsID:  tableForeignKey(parseTime, idx),
+			CommentsID:  ids.NextID(),
 			Timestamp:   c.Timestamp,
-			Date:        dateOfTime(t),
-			Weekday:     weekdayOfTime(t),
+			Date:        DateOfTime(t),
+			Weekday:     WeekdayOfTime(t),
 			DataOwnerID: owner,
+			ArchiveID:   archiveID,
 		}
 		if len(c.Data) > 0 {
 			orm.Author = string(c.Data[0].Comment.Author)
 			orm.Comment = string(c.Data[0].Comment.Comment)
+			if c.Data[0].Comment.Group != "" {
+				var group *GroupORM
+				orm.GroupID, group = groupIDs.Add(ids, owner, archiveID, string(c.Data[0].Comment.Group))
+				if group != nil {
+					groups = append(groups, *group)
+				}
+			}
 		}
 
 		result = append(result, orm)
-		idx++
 	}
-	return result
+	return append(groups, result...)
 }
@@ -0,0 +1,316 @@
+package facebook
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+const (
+	DriftUnknownField = "unknown_field"
+	DriftMissingField = "missing_field"
+	DriftTypeChange   = "type_change"
+)
+
+// Drift is how a file differs from the raw type that it is decoded into.
+// Paths are dot separated keys, where [] stands for the items of an array and * for the values of a map.
+type Drift struct {
+	UnknownFields []string      `json:"unknown_fields,omitempty"`
+	MissingFields []string      `json:"missing_fields,omitempty"`
+	TypeChanges   []*TypeChange `json:"type_changes,omitempty"`
+
+	seen map[string]bool
+}
+
+type TypeChange struct {
+	Path     string `json:"path"`
+	Expected string `json:"expected"`
+	Found    string `json:"found"`
+}
+
+func (d *Drift) Empty() bool {
+	return len(d.UnknownFields) == 0 && len(d.MissingFields) == 0 && len(d.TypeChanges) == 0
+}
+
+// AnalyzeDrift compares the file with the raw type t. A path is reported once however many
+// items of arrays it is found in.
+func AnalyzeDrift(t reflect.Type, data []byte) (*Drift, error) {
+	var doc interface{}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	if err := dec.Decode(&doc); err != nil {
+		return nil, err
+	}
+
+	d := &Drift{seen: make(map[string]bool)}
+	d.walk(t, doc, "")
+	return d, nil
+}
+
+func (d *Drift) once(kind, path string) bool {
+	key := kind + " " + path
+	if d.seen[key] {
+		return false
+	}
+	d.seen[key] = true
+	return true
+}
+
+func (d *Drift) unknownField(path string) {
+	if d.once(DriftUnknownField, path) {
+		d.UnknownFields = append(d.UnknownFields, path)
+	}
+}
+
+func (d *Drift) missingField(path string) {
+	if d.once(DriftMissingField, path) {
+		d.MissingFields = append(d.MissingFields, path)
+	}
+}
+
+func (d *Drift) typeChange(path, expected string, v interface{}) {
+	if d.once(DriftTypeChange, path) {
+		d.TypeChanges = append(d.TypeChanges, &TypeChange{Path: path, Expected: expected, Found: jsonTypeOf(v)})
+	}
+}
+
+func (d *Drift) walk(t reflect.Type, v interface{}, path string) {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	// null is decoded into the zero value of any type
+	if v == nil {
+		return
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		object, ok := v.(map[string]interface{})
+		if !ok {
+			d.typeChange(path, "object", v)
+			return
+		}
+
+		fields := jsonFieldsOf(t)
+		for _, f := range fields {
+			value, ok := object[f.name]
+			if !ok {
+				if f.required {
+					d.missingField(joinPath(path, f.name))
+				}
+				continue
+			}
+			d.walk(f.typ, value, joinPath(path, f.name))
+		}
+
+		keys := make([]string, 0)
+		for key := range object {
+			if !fields.has(key) {
+				keys = append(keys, key)
+			}
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			d.unknownField(joinPath(path, key))
+		}
+	case reflect.Slice, reflect.Array:
+		items, ok := v.([]interface{})
+		if !ok {
+			d.typeChange(path, "array", v)
+			return
+		}
+		for _, item := range items {
+			d.walk(t.Elem(), item, path+"[]")
+		}
+	case reflect.Map:
+		object, ok := v.(map[string]interface{})
+		if !ok {
+			d.typeChange(path, "object", v)
+			return
+		}
+		for _, value := range object {
+			d.walk(t.Elem(), value, joinPath(path, "*"))
+		}
+	case reflect.String:
+		if _, ok := v.(string); !ok {
+			d.typeChange(path, "string", v)
+		}
+	case reflect.Bool:
+		if _, ok := v.(bool); !ok {
+			d.typeChange(path, "boolean", v)
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, ok := v.(json.Number)
+		if !ok {
+			d.typeChange(path, "integer", v)
+			return
+		}
+		if _, err := n.Int64(); err != nil {
+			d.typeChange(path, "integer", v)
+		}
+	case reflect.Float32, reflect.Float64:
+		if _, ok := v.(json.Number); !ok {
+			d.typeChange(path, "number", v)
+		}
+	}
+}
+
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	if key == "" {
+		return path
+	}
+	return path + "." + key
+}
+
+// jsonTypeOf returns the JSON type of a decoded value.
+func jsonTypeOf(v interface{}) string {
+	switch n := v.(type) {
+	case map[string]interface{}:
+		return "object"
+	case []interface{}:
+		return "array"
+	case string:
+		return "string"
+	case bool:
+		return "boolean"
+	case json.Number:
+		if _, err := n.Int64(); err == nil {
+			return "integer"
+		}
+		return "number"
+	default:
+		return "null"
+	}
+}
+
+type jsonField struct {
+	name     string
+	typ      reflect.Type
+	required bool
+}
+
+type jsonFields []jsonField
+
+func (fields jsonFields) has(name string) bool {
+	for _, f := range fields {
+		if f.name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// jsonFieldsOf returns the fields of the struct type by their names in JSON.
+func jsonFieldsOf(t reflect.Type) jsonFields {
+	fields := make(jsonFields, 0, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.PkgPath != "" {
+			continue
+		}
+
+		name := f.Name
+		if tag := f.Tag.Get("json"); tag != "" {
+			if tag == "-" {
+				continue
+			}
+			if n := strings.Split(tag, ",")[0]; n != "" {
+				name = n
+			}
+		}
+		required := false
+		for _, option := range strings.Split(f.Tag.Get("jsonschema"), ",") {
+			required = required || option == "required"
+		}
+		fields = append(fields, jsonField{name: name, typ: f.Type, required: required})
+	}
+	return fields
+}
+
+// DriftORM counts the files, or the items of streamed files, having the same drift from a raw type.
+type DriftORM struct {
+	Pattern       string
+	SchemaVersion string
+	Kind          string
+	Path          string
+	Expected      string
+	Found         string
+	Count         int
+	DataOwnerID   string
+	ArchiveID     string
+}
+
+func (DriftORM) TableName() string {
+	return "parser_schemadrift"
+}
+
+// DriftStats counts the drifts found while parsing an archive.
+type DriftStats struct {
+	counts map[DriftORM]int
+	// drifts are kept in the order that they are first found
+	drifts []DriftORM
+}
+
+func NewDriftStats() *DriftStats {
+	return &DriftStats{counts: make(map[DriftORM]int)}
+}
+
+// Add counts the drift of a file of the pattern, the prefix is the path of the file part analyzed.
+func (s *DriftStats) Add(pattern string, version *SchemaVersion, prefix string, d *Drift) {
+	add := func(kind, path, expected, found string) {
+		key := DriftORM{
+			Pattern:       pattern,
+			SchemaVersion: version.VersionName(),
+			Kind:          kind,
+			Path:          joinPath(prefix, path),
+			Expected:      expected,
+			Found:         found,
+		}
+		if _, ok := s.counts[key]; !ok {
+			s.drifts = append(s.drifts, key)
+		}
+		s.counts[key]++
+	}
+
+	for _, path := range d.UnknownFields {
+		add(DriftUnknownField, path, "", "")
+	}
+	for _, path := range d.MissingFields {
+		add(DriftMissingField, path, "", "")
+	}
+	for _, c := range d.TypeChanges {
+		add(DriftTypeChange, c.Path, c.Expected, c.Found)
+	}
+}
+
+// ORM returns the drifts with their counts.
+func (s *DriftStats) ORM(owner, archiveID string) []interface{} {
+	result := make([]interface{}, 0, len(s.drifts))
+	for _, key := range s.drifts {
+		orm := key
+		orm.Count = s.counts[key]
+		orm.DataOwnerID = owner
+		orm.ArchiveID = archiveID
+		result = append(result, orm)
+	}
+	return result
+}
+
+// recordDrift analyzes the file, or a part of it at the prefix, against the raw type t. A file
+// that is not JSON is not analyzed, as it is left for the validation to report.
+func (ctx *ParseContext) recordDrift(p *Pattern, version *SchemaVersion, prefix string, t reflect.Type, data []byte) {
+	if t == nil {
+		return
+	}
+	d, err := AnalyzeDrift(t, data)
+	if err != nil {
+		return
+	}
+	ctx.Drifts.Add(p.Name, version, prefix, d)
+}
@@ -0,0 +1,51 @@
+package facebook
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAnalyzeDrift(t *testing.T) {
+	d, err := AnalyzeDrift(reflect.TypeOf(&RawFriends{}), []byte(`{"friends": [
+		{"name": "Alice", "timestamp": 1578201080, "phone": "123"},
+		{"name": "Bob", "timestamp": "yesterday", "phone": "456"},
+		{"timestamp": 1578201100, "contact_info": null}
+	], "total": 3}`))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"friends[].phone", "total"}, d.UnknownFields)
+	assert.Equal(t, []string{"friends[].name"}, d.MissingFields)
+	assert.Equal(t, []*TypeChange{{Path: "friends[].timestamp", Expected: "integer", Found: "string"}}, d.TypeChanges)
+
+	d, err = AnalyzeDrift(reflect.TypeOf(&RawFriends{}), []byte(`{"friends": [{"name": "Alice", "timestamp": 1578201080}]}`))
+	assert.NoError(t, err)
+	assert.True(t, d.Empty())
+
+	_, err = AnalyzeDrift(reflect.TypeOf(&RawFriends{}), []byte(`{"friends": `))
+	assert.Error(t, err)
+}
+
+func TestDriftStats(t *testing.T) {
+	stats := NewDriftStats()
+	stats.Add("comments", nil, "comments[]", &Drift{UnknownFields: []string{"author"}})
+	stats.Add("comments", nil, "comments[]", &Drift{UnknownFields: []string{"author"}, MissingFields: []string{"title"}})
+	stats.Add("friends", &FriendsV2, "", &Drift{TypeChanges: []*TypeChange{{Path: "friends", Expected: "array", Found: "object"}}})
+
+	drifts := stats.ORM("owner", "archive")
+	if assert.Len(t, drifts, 3) {
+		assert.Equal(t, DriftORM{
+			Pattern:       "comments",
+			SchemaVersion: CurrentSchemaVersion,
+			Kind:          DriftUnknownField,
+			Path:          "comments[].author",
+			Count:         2,
+			DataOwnerID:   "owner",
+			ArchiveID:     "archive",
+		}, drifts[0])
+		assert.Equal(t, 1, drifts[1].(DriftORM).Count)
+		assert.Equal(t, "comments[].title", drifts[1].(DriftORM).Path)
+		assert.Equal(t, "v2", drifts[2].(DriftORM).SchemaVersion)
+		assert.Equal(t, "object", drifts[2].(DriftORM).Found)
+	}
+}
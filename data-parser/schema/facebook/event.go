@@ -0,0 +1,76 @@
+package facebook
+
+import (
+	"github.com/xeipuuv/gojsonschema"
+)
+
+const (
+	EventResponseJoined     = "joined"
+	EventResponseDeclined   = "declined"
+	EventResponseInterested = "interested"
+	EventResponseInvited    = "invited"
+)
+
+type RawEventResponses struct {
+	EventResponses *EventResponses `json:"event_responses" jsonschema:"required"`
+}
+
+type EventResponses struct {
+	EventsJoined     []*Event `json:"events_joined"`
+	EventsDeclined   []*Event `json:"events_declined"`
+	EventsInterested []*Event `json:"events_interested"`
+}
+
+type RawEventInvitations struct {
+	EventsInvited []*Event `json:"events_invited" jsonschema:"required"`
+}
+
+func EventResponseSchemaLoader() *gojsonschema.Schema {
+	return SchemaLoaderOf(&RawEventResponses{})
+}
+
+func EventInvitationSchemaLoader() *gojsonschema.Schema {
+	return SchemaLoaderOf(&RawEventInvitations{})
+}
+
+// EventResponseORM is an event the data owner has responded to or been invited to.
+type EventResponseORM struct {
+	EventID        int64
+	Name           string
+	StartTimestamp int
+	EndTimestamp   int
+	Response       string
+	DataOwnerID    string
+	ArchiveID      string
+}
+
+func (EventResponseORM) TableName() string {
+	return "events_eventresponse"
+}
+
+func eventResponsesORM(ids IDGenerator, events []*Event, response, owner, archiveID string) []interface{} {
+	result := make([]interface{}, 0)
+	for _, e := range events {
+		result = append(result, EventResponseORM{
+			EventID:        ids.NextID(),
+			Name:           string(e.Name),
+			StartTimestamp: e.StartTimestamp,
+			EndTimestamp:   e.EndTimestamp,
+			Response:       response,
+			DataOwnerID:    owner,
+			ArchiveID:      archiveID,
+		})
+	}
+	return result
+}
+
+func (r RawEventResponses) ORM(ids IDGenerator, owner, archiveID string) []interface{} {
+	result := eventResponsesORM(ids, r.EventResponses.EventsJoined, EventResponseJoined, owner, archiveID)
+	result = append(result, eventResponsesORM(ids, r.EventResponses.EventsDeclined, EventResponseDeclined, owner, archiveID)...)
+	result = append(result, eventResponsesORM(ids, r.EventResponses.EventsInterested, EventResponseInterested, owner, archiveID)...)
+	return result
+}
+
+func (r RawEventInvitations) ORM(ids IDGenerator, owner, archiveID string) []interface{} {
+	return eventResponsesORM(ids, r.EventsInvited, EventResponseInvited, owner, archiveID)
+}
@@ -0,0 +1,97 @@
+package facebook
+
+import (
+	"github.com/xeipuuv/gojsonschema"
+)
+
+const (
+	FollowTypeFollower     = "follower"
+	FollowTypeFollowing    = "following"
+	FollowTypeFollowedPage = "followed_page"
+)
+
+type RawFollowers struct {
+	Followers []*Follow `json:"followers" jsonschema:"required"`
+}
+
+type RawFollowing struct {
+	Following []*Follow `json:"following" jsonschema:"required"`
+}
+
+type Follow struct {
+	Name      MojibakeString `json:"name" jsonschema:"required"`
+	Timestamp int            `json:"timestamp"`
+}
+
+type RawFollowedPages struct {
+	PagesFollowed []*FollowedPage `json:"pages_followed" jsonschema:"required"`
+}
+
+type FollowedPage struct {
+	Timestamp int            `json:"timestamp" jsonschema:"required"`
+	Title     MojibakeString `json:"title"`
+	Data      []*Follow      `json:"data" jsonschema:"required"`
+}
+
+func FollowerSchemaLoader() *gojsonschema.Schema {
+	return SchemaLoaderOf(&RawFollowers{})
+}
+
+func FollowingSchemaLoader() *gojsonschema.Schema {
+	return SchemaLoaderOf(&RawFollowing{})
+}
+
+func FollowedPageSchemaLoader() *gojsonschema.Schema {
+	return SchemaLoaderOf(&RawFollowedPages{})
+}
+
+// FollowORM is a follower of the data owner, or a person or page followed by the data owner.
+type FollowORM struct {
+	Name        string
+	Timestamp   int
+	Type        string
+	DataOwnerID string
+	ArchiveID   string
+}
+
+func (FollowORM) TableName() string {
+	return "following_follow"
+}
+
+func followsORM(follows []*Follow, followType, owner, archiveID string) []interface{} {
+	result := make([]interface{}, 0)
+	for _, f := range follows {
+		result = append(result, FollowORM{
+			Name:        string(f.Name),
+			Timestamp:   f.Timestamp,
+			Type:        followType,
+			DataOwnerID: owner,
+			ArchiveID:   archiveID,
+		})
+	}
+	return result
+}
+
+func (r RawFollowers) ORM(owner, archiveID string) []interface{} {
+	return followsORM(r.Followers, FollowTypeFollower, owner, archiveID)
+}
+
+func (r RawFollowing) ORM(owner, archiveID string) []interface{} {
+	return followsORM(r.Following, FollowTypeFollowing, owner, archiveID)
+}
+
+func (r RawFollowedPages) ORM(owner, archiveID string) []interface{} {
+	result := make([]interface{}, 0)
+	for _, p := range r.PagesFollowed {
+		for _, f := range p.Data {
+			result = append(result, FollowORM{
+				Name:        string(f.Name),
+				Timestamp:   p.Timestamp,
+				Type:        FollowTypeFollowedPage,
+				DataOwnerID: owner,
+				ArchiveID:   archiveID,
+			})
+		}
+	}
+	return result
+}
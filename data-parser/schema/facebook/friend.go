@@ -1,7 +1,8 @@
 package facebook
 
 import (
-	"github.com/alecthomas/jsonschema"
+	"time"
+
 	"github.com/xeipuuv/gojsonschema"
 )
 
@@ -16,16 +17,7 @@ type Friend struct {
 }
 
 func FriendSchemaLoader() *gojsonschema.Schema {
-	reflector := jsonschema.Reflector{
-		AllowAdditionalProperties:  false,
-		ExpandedStruct:             true,
-		RequiredFromJSONSchemaTags: true,
-	}
-	s := reflector.Reflect(&RawFriends{})
-	data, _ := s.MarshalJSON()
-	schemaLoader := gojsonschema.NewStringLoader(string(data))
-	schema, _ := gojsonschema.NewSchema(schemaLoader)
-	return schema
+	return SchemaLoaderOf(&RawFriends{})
 }
 
 type FriendORM struct {
@@ -34,6 +26,7 @@ type FriendORM struct {
 	FriendName  string
 	Timestamp   int
 	DataOwnerID string
+	ArchiveID   string
 }
 
 func (FriendORM) TableName() string {
@@ -41,8 +34,7 @@ func (FriendORM) TableName() string {
 }
 
 // FIXME: friends can have the same name
-func (r RawFriends) ORM(parseTime int64, owner string) []interface{} {
-	idx := 0
+func (r RawFriends) ORM(ids IDGenerator, owner, archiveID string) []interface{} {
 	result := make([]interface{}, 0)
 
 	seen := make(map[string]bool)
@@ -54,14 +46,82 @@ func (r RawFriends) ORM(parseTime int64, owner string) []interface{} {
 		seen[name] = true
 
 		orm := FriendORM{
-			FriendID:    tableForeignKey(parseTime, idx),
+			FriendID:    ids.NextID(),
 			FriendName:  name,
 			Timestamp:   f.Timestamp,
 			DataOwnerID: owner,
+			ArchiveID:   archiveID,
 		}
 
 		result = append(result, orm)
-		idx++
+	}
+	return result
+}
+
+// RawFriendshipEvents is any of the friend request and removed friend files,
+// each of them has only one of the lists.
+type RawFriendshipEvents struct {
+	SentRequests     []*Friend `json:"sent_requests"`
+	ReceivedRequests []*Friend `json:"received_requests"`
+	RejectedRequests []*Friend `json:"rejected_requests"`
+	DeletedFriends   []*Friend `json:"deleted_friends"`
+}
+
+func FriendshipEventSchemaLoader() *gojsonschema.Schema {
+	return SchemaLoaderOf(&RawFriendshipEvents{})
+}
+
+const (
+	FriendshipEventSentRequest     = "sent_request"
+	FriendshipEventReceivedRequest = "received_request"
+	FriendshipEventRejectedRequest = "rejected_request"
+	FriendshipEventRemoved         = "removed"
+)
+
+type FriendshipEventORM struct {
+	EventID     int64
+	Type        string
+	FriendName  string
+	FriendPKID  *int `gorm:"column:friend_pk_id"` // the friend of the same name, if any
+	Timestamp   int
+	Date        string
+	Weekday     int
+	DataOwnerID string
+	ArchiveID   string
+}
+
+func (FriendshipEventORM) TableName() string {
+	return "friends_friendshipevent"
+}
+
+// ORM links the events to the friends by names, friendIDs maps friend names to primary keys.
+func (r RawFriendshipEvents) ORM(ids IDGenerator, owner, archiveID string, friendIDs map[string]int) []interface{} {
+	result := make([]interface{}, 0)
+
+	events := map[string][]*Friend{
+		FriendshipEventSentRequest:     r.SentRequests,
+		FriendshipEventReceivedRequest: r.ReceivedRequests,
+		FriendshipEventRejectedRequest: r.RejectedRequests,
+		FriendshipEventRemoved:         r.DeletedFriends,
+	}
+	for _, eventType := range []string{FriendshipEventSentRequest, FriendshipEventReceivedRequest, FriendshipEventRejectedRequest, FriendshipEventRemoved} {
+		for _, f := range events[eventType] {
+			t := time.Unix(int64(f.Timestamp), 0)
+			orm := FriendshipEventORM{
+				EventID:     ids.NextID(),
+				Type:        eventType,
+				FriendName:  string(f.Name),
+				Timestamp:   f.Timestamp,
+				Date:        DateOfTime(t),
+				Weekday:     WeekdayOfTime(t),
+				DataOwnerID: owner,
+				ArchiveID:   archiveID,
+			}
+			if pkID, ok := friendIDs[orm.FriendName]; ok {
+				orm.FriendPKID = &pkID
+			}
+			result = append(result, orm)
+		}
 	}
 	return result
 }
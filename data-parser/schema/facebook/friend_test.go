@@ -0,0 +1,70 @@
+package facebook
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFriendshipEventsPattern(t *testing.T) {
+	cases := map[string]testCase{
+		"/tmp/user-a/friends/sent_friend_requests.json":     {`{"sent_requests":[{"name":"Alice","timestamp":1578201080}]}`, true},
+		"/tmp/user-a/friends/received_friend_requests.json": {`{"received_requests":[{"name":"Bob","timestamp":1578201090}]}`, true},
+		"/tmp/user-a/friends/rejected_friend_requests.json": {`{"rejected_requests":[{"name":"Carol"}]}`, false},
+		"/tmp/user-a/friends/removed_friends.json":          {`{"deleted_friends":[{"name":"Dave","timestamp":1578201100}]}`, true},
+		"/tmp/user-a/friends/friends.json":                  {`DOESN'T MATTER`, false},
+		"/tmp/user-a/friends/removed_friends.json.bak":      {`DOESN'T MATTER`, false},
+	}
+	fs := afero.NewMemMapFs()
+	for filename, item := range cases {
+		afero.WriteFile(fs, filename, []byte(item.content), 0644)
+	}
+
+	p := FriendshipEventsPattern
+	filenames, err := p.SelectFiles(fs, "/tmp/user-a/friends")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{
+		"/tmp/user-a/friends/received_friend_requests.json",
+		"/tmp/user-a/friends/rejected_friend_requests.json",
+		"/tmp/user-a/friends/removed_friends.json",
+		"/tmp/user-a/friends/sent_friend_requests.json",
+	}, filenames)
+
+	for _, n := range filenames {
+		data, err := afero.ReadFile(fs, n)
+		assert.NoError(t, err)
+		assert.Equal(t, cases[n].valid, p.Validate(data) == nil, n)
+	}
+}
+
+func TestFriendshipEventsORM(t *testing.T) {
+	var raw RawFriendshipEvents
+	assert.NoError(t, json.Unmarshal([]byte(`{
+		"sent_requests":[{"name":"Alice","timestamp":1578201080}],
+		"deleted_friends":[{"name":"Bob","timestamp":1578201090},{"name":"Carol","timestamp":1578201100}]
+	}`), &raw))
+
+	// Carol isn't in friends.json any more
+	events := raw.ORM(&sequence{}, "owner", "archive", map[string]int{"Alice": 10, "Bob": 20})
+	assert.Len(t, events, 3)
+
+	alice := events[0].(FriendshipEventORM)
+	assert.Equal(t, int64(1), alice.EventID)
+	assert.Equal(t, FriendshipEventSentRequest, alice.Type)
+	assert.Equal(t, "Alice", alice.FriendName)
+	assert.Equal(t, 10, *alice.FriendPKID)
+	assert.Equal(t, 1578201080, alice.Timestamp)
+	assert.Equal(t, "owner", alice.DataOwnerID)
+	assert.Equal(t, "archive", alice.ArchiveID)
+
+	bob := events[1].(FriendshipEventORM)
+	assert.Equal(t, FriendshipEventRemoved, bob.Type)
+	assert.Equal(t, 20, *bob.FriendPKID)
+
+	carol := events[2].(FriendshipEventORM)
+	assert.Equal(t, FriendshipEventRemoved, carol.Type)
+	assert.Equal(t, "Carol", carol.FriendName)
+	assert.Nil(t, carol.FriendPKID)
+}
@@ -0,0 +1,191 @@
+package facebook
+
+import (
+	"strings"
+	"time"
+
+	"github.com/xeipuuv/gojsonschema"
+)
+
+const (
+	GroupActivityPost    = "post"
+	GroupActivityComment = "comment"
+)
+
+type RawGroupMembership struct {
+	GroupsJoined []*GroupMembershipActivity `json:"groups_joined" jsonschema:"required"`
+}
+
+type GroupMembershipActivity struct {
+	Timestamp int                `json:"timestamp" jsonschema:"required"`
+	Title     MojibakeString     `json:"title"`
+	Data      []*GroupMembership `json:"data" jsonschema:"required"`
+}
+
+type GroupMembership struct {
+	Name MojibakeString `json:"name" jsonschema:"required"`
+}
+
+type RawGroupActivities struct {
+	GroupPosts *GroupActivityLog `json:"group_posts" jsonschema:"required"`
+}
+
+type GroupActivityLog struct {
+	ActivityLogData []*GroupActivity `json:"activity_log_data" jsonschema:"required"`
+}
+
+type GroupActivity struct {
+	Timestamp   int                  `json:"timestamp" jsonschema:"required"`
+	Title       MojibakeString       `json:"title"`
+	Data        []*GroupActivityData `json:"data"`
+	Attachments []*Attachment        `json:"attachments"`
+}
+
+// GroupActivityData has either a post or a comment.
+type GroupActivityData struct {
+	Post            MojibakeString `json:"post"`
+	UpdateTimestamp int            `json:"update_timestamp"`
+	Comment         *CommentData   `json:"comment"`
+}
+
+func GroupMembershipSchemaLoader() *gojsonschema.Schema {
+	return SchemaLoaderOf(&RawGroupMembership{})
+}
+
+func GroupActivitySchemaLoader() *gojsonschema.Schema {
+	return SchemaLoaderOf(&RawGroupActivities{})
+}
+
+type GroupORM struct {
+	GroupID         int64
+	Name            string
+	JoinedTimestamp int
+	DataOwnerID     string
+	ArchiveID       string
+}
+
+func (GroupORM) TableName() string {
+	return "groups_group"
+}
+
+// GroupActivityORM is a post or a comment of the data owner in a group.
+type GroupActivityORM struct {
+	ActivityID  int64
+	GroupID     *int64
+	Type        string
+	Title       string
+	Content     string
+	Author      string
+	Timestamp   int
+	Date        string
+	Weekday     int
+	DataOwnerID string
+	ArchiveID   string
+}
+
+func (GroupActivityORM) TableName() string {
+	return "groups_activity"
+}
+
+// GroupIDs maps group names to the ids of the groups parsed from an archive.
+type GroupIDs map[string]int64
+
+// ID returns the id of the group with the name, or nil if the group is unknown.
+func (g GroupIDs) ID(name string) *int64 {
+	if id, ok := g[name]; ok {
+		return &id
+	}
+	return nil
+}
+
+// Add returns the id of the group with the name. A group that isn't known, like one the data owner has
+// left since, is added to g and returned as a new row to be inserted before the rows referring to it.
+func (g GroupIDs) Add(ids IDGenerator, owner, archiveID, name string) (*int64, *GroupORM) {
+	if id := g.ID(name); id != nil {
+		return id, nil
+	}
+	g[name] = ids.NextID()
+	group := &GroupORM{
+		GroupID:     g[name],
+		Name:        name,
+		DataOwnerID: owner,
+		ArchiveID:   archiveID,
+	}
+	return g.ID(name), group
+}
+
+// ORM returns the groups the data owner has joined and adds them to groupIDs.
+func (r RawGroupMembership) ORM(ids IDGenerator, owner, archiveID string, groupIDs GroupIDs) []interface{} {
+	result := make([]interface{}, 0)
+	for _, a := range r.GroupsJoined {
+		for _, g := range a.Data {
+			name := string(g.Name)
+			if _, ok := groupIDs[name]; ok {
+				continue
+			}
+			groupIDs[name] = ids.NextID()
+
+			result = append(result, GroupORM{
+				GroupID:         groupIDs[name],
+				Name:            name,
+				JoinedTimestamp: a.Timestamp,
+				DataOwnerID:     owner,
+				ArchiveID:       archiveID,
+			})
+		}
+	}
+	return result
+}
+
+// ORM returns the posts and comments of the data owner in groups, preceded by the groups of the comments
+// which aren't known yet. The group of a comment is given by the comment, while the group of a post is
+// only mentioned in its title, such as "Alice posted in Gophers.", so it is looked up among the known groups.
+func (r RawGroupActivities) ORM(ids IDGenerator, owner, archiveID string, groupIDs GroupIDs) []interface{} {
+	groups := make([]interface{}, 0)
+	result := make([]interface{}, 0)
+	for _, a := range r.GroupPosts.ActivityLogData {
+		t := time.Unix(int64(a.Timestamp), 0)
+		orm := GroupActivityORM{
+			ActivityID:  ids.NextID(),
+			Type:        GroupActivityPost,
+			Title:       string(a.Title),
+			Timestamp:   a.Timestamp,
+			Date:        DateOfTime(t),
+			Weekday:     WeekdayOfTime(t),
+			DataOwnerID: owner,
+			ArchiveID:   archiveID,
+		}
+		for _, d := range a.Data {
+			if d.Post != "" {
+				orm.Content = string(d.Post)
+			}
+			if d.Comment != nil {
+				orm.Type = GroupActivityComment
+				orm.Content = string(d.Comment.Comment)
+				orm.Author = string(d.Comment.Author)
+				if d.Comment.Group != "" {
+					var group *GroupORM
+					orm.GroupID, group = groupIDs.Add(ids, owner, archiveID, string(d.Comment.Group))
+					if group != nil {
+						groups = append(groups, *group)
+					}
+				}
+			}
+		}
+		if orm.GroupID == nil {
+			// the longest name wins if a group name ends with another one
+			matched := ""
+			for name := range groupIDs {
+				if len(name) > len(matched) && strings.HasSuffix(orm.Title, " in "+name+".") {
+					matched = name
+				}
+			}
+			if matched != "" {
+				orm.GroupID = groupIDs.ID(matched)
+			}
+		}
+
+		result = append(result, orm)
+	}
+	return append(groups, result...)
+}
@@ -0,0 +1,60 @@
+package facebook
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type sequence struct {
+	id int64
+}
+
+func (s *sequence) NextID() int64 {
+	s.id++
+	return s.id
+}
+
+func TestGroupLinkage(t *testing.T) {
+	ids := &sequence{}
+	groupIDs := make(GroupIDs)
+
+	membership := RawGroupMembership{GroupsJoined: []*GroupMembershipActivity{
+		{Timestamp: 1578201080, Data: []*GroupMembership{{Name: "Gophers"}, {Name: "Go"}}},
+		{Timestamp: 1578201090, Data: []*GroupMembership{{Name: "Gophers"}}},
+	}}
+	groups := membership.ORM(ids, "owner", "archive", groupIDs)
+	assert.Len(t, groups, 2)
+	assert.Equal(t, GroupIDs{"Gophers": 1, "Go": 2}, groupIDs)
+
+	activities := RawGroupActivities{GroupPosts: &GroupActivityLog{ActivityLogData: []*GroupActivity{
+		{Timestamp: 1578201080, Title: "Me posted in Gophers.", Data: []*GroupActivityData{{Post: "hello"}}},
+		{Timestamp: 1578201090, Title: "Me commented on a post.", Data: []*GroupActivityData{{Comment: &CommentData{Comment: "nice", Group: "Go"}}}},
+		{Timestamp: 1578201100, Title: "Me posted in Rustaceans.", Data: []*GroupActivityData{{Post: "hi"}}},
+		{Timestamp: 1578201110, Title: "Me commented on a post.", Data: []*GroupActivityData{{Comment: &CommentData{Comment: "hey", Group: "Gleam"}}}},
+	}}}.ORM(ids, "owner", "archive", groupIDs)
+	// the group left since comes first
+	assert.Len(t, activities, 5)
+	assert.Equal(t, GroupORM{GroupID: 7, Name: "Gleam", DataOwnerID: "owner", ArchiveID: "archive"}, activities[0])
+	assert.Equal(t, int64(1), *activities[1].(GroupActivityORM).GroupID)
+	assert.Equal(t, GroupActivityComment, activities[2].(GroupActivityORM).Type)
+	assert.Equal(t, int64(2), *activities[2].(GroupActivityORM).GroupID)
+	// the group of a post is only looked up by its title
+	assert.Nil(t, activities[3].(GroupActivityORM).GroupID)
+	assert.Equal(t, int64(7), *activities[4].(GroupActivityORM).GroupID)
+
+	comments := RawComments{Comments: []Comment{
+		{Timestamp: 1578201090, Title: "c", Data: []*CommentWrapper{{Comment: CommentData{Comment: "nice", Group: "Gophers"}}}},
+		{Timestamp: 1578201100, Title: "c", Data: []*CommentWrapper{{Comment: CommentData{Comment: "hi"}}}},
+		{Timestamp: 1578201110, Title: "c", Data: []*CommentWrapper{{Comment: CommentData{Comment: "bye", Group: "Elm"}}}},
+		{Timestamp: 1578201120, Title: "c", Data: []*CommentWrapper{{Comment: CommentData{Comment: "again", Group: "Gleam"}}}},
+	}}.ORM(ids, "owner", "archive", groupIDs)
+	assert.Len(t, comments, 5)
+	assert.Equal(t, "Elm", comments[0].(GroupORM).Name)
+	assert.Equal(t, int64(1), *comments[1].(CommentORM).GroupID)
+	assert.Nil(t, comments[2].(CommentORM).GroupID)
+	assert.Equal(t, comments[0].(GroupORM).GroupID, *comments[3].(CommentORM).GroupID)
+	assert.Equal(t, int64(7), *comments[4].(CommentORM).GroupID)
+	assert.Equal(t, int64(2), groupIDs["Go"])
+	assert.Len(t, groupIDs, 4)
+}
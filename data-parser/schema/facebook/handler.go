@@ -0,0 +1,303 @@
+package facebook
+
+import (
+	"encoding/json"
+	"fmt"
+	"path"
+	"reflect"
+	"strings"
+
+	"github.com/spf13/afero"
+
+	"github.com/bitmark-inc/datapod/data-parser/storage"
+)
+
+// Sink receives the rows parsed from an archive.
+type Sink interface {
+	BulkInsert(rows []interface{}) error
+	Create(row interface{}) error
+	// FriendIDs maps friend names to the primary keys of the friends parsed from an archive.
+	FriendIDs(dataOwner, archiveID string) (map[string]int, error)
+}
+
+// ParseContext is shared by the handlers parsing an archive.
+type ParseContext struct {
+	IDs       IDGenerator
+	DataOwner string
+	ArchiveID string
+	Sink      Sink
+	Store     storage.ObjectStore
+	// MediaKeyPrefix is the object key prefix of the media files of the archive
+	MediaKeyPrefix string
+	// BatchSize is the number of rows persisted at once while streaming a file
+	BatchSize int
+	// Strict parsing fails on the first invalid record, otherwise invalid records are quarantined
+	Strict bool
+	// Quarantined are the invalid records left out. They are persisted through QuarantineSink in batches
+	// of BatchSize, and the rest by FlushQuarantine. They are kept until the caller persists them if
+	// QuarantineSink is nil.
+	Quarantined    []interface{}
+	QuarantineSink Sink
+	// QuarantinedCount is the number of invalid records left out, including those persisted
+	QuarantinedCount int
+	// Drifts of the files from their raw types
+	Drifts *DriftStats
+
+	// the state handed over from a handler to the following ones
+	Places          *PlaceTimeline
+	GroupIDs        GroupIDs
+	ConversationIDs map[string]int64
+}
+
+func NewParseContext(ids IDGenerator, dataOwner, archiveID string, sink Sink, store storage.ObjectStore, mediaKeyPrefix string) *ParseContext {
+	return &ParseContext{
+		IDs:             ids,
+		DataOwner:       dataOwner,
+		ArchiveID:       archiveID,
+		Sink:            sink,
+		Store:           store,
+		MediaKeyPrefix:  mediaKeyPrefix,
+		BatchSize:       DefaultBatchSize,
+		Places:          NewPlaceTimeline(),
+		GroupIDs:        make(GroupIDs),
+		ConversationIDs: make(map[string]int64),
+		Drifts:          NewDriftStats(),
+	}
+}
+
+func (ctx *ParseContext) batchSize() int {
+	if ctx.BatchSize <= 0 {
+		return DefaultBatchSize
+	}
+	return ctx.BatchSize
+}
+
+// Handler parses a data category of an archive.
+// Its pattern locates, selects and validates the files, which are then decoded,
+// transformed into rows and persisted one by one.
+type Handler interface {
+	Pattern() *Pattern
+	// Models are the models of the rows, in the order that they are inserted.
+	Models() []interface{}
+	// Requires are the names of the patterns whose rows or state the handler refers to.
+	Requires() []string
+	// RawType is the type that a file is decoded into, it is nil if files are not decoded.
+	RawType() reflect.Type
+	Decode(data []byte) (interface{}, error)
+	Transform(ctx *ParseContext, file string, v interface{}) ([]interface{}, error)
+	Persist(ctx *ParseContext, rows []interface{}) error
+	// Finish is called with the dir of the pattern once all files are parsed.
+	Finish(ctx *ParseContext, fs afero.Fs, dir string) error
+}
+
+// Category is a Handler made of functions.
+type Category struct {
+	Files  Pattern
+	Tables []interface{}
+	// NewRaw returns a pointer to the value that a file is decoded into.
+	NewRaw        func() interface{}
+	TransformFunc func(ctx *ParseContext, file string, v interface{}) ([]interface{}, error)
+	// PersistFunc defaults to inserting the rows of each model in one go.
+	PersistFunc func(ctx *ParseContext, rows []interface{}) error
+	// Media categories upload the files other than those selected by the pattern.
+	Media bool
+	// Needs are the names of the patterns whose rows or state the category refers to.
+	Needs []string
+}
+
+func (c *Category) Pattern() *Pattern {
+	return &c.Files
+}
+
+func (c *Category) Models() []interface{} {
+	return c.Tables
+}
+
+func (c *Category) Requires() []string {
+	return c.Needs
+}
+
+func (c *Category) RawType() reflect.Type {
+	if c.NewRaw == nil {
+		return nil
+	}
+	return reflect.TypeOf(c.NewRaw())
+}
+
+func (c *Category) Decode(data []byte) (interface{}, error) {
+	if c.NewRaw == nil {
+		return nil, nil
+	}
+	v := c.NewRaw()
+	if err := json.Unmarshal(data, v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+func (c *Category) Transform(ctx *ParseContext, file string, v interface{}) ([]interface{}, error) {
+	if c.TransformFunc == nil {
+		return nil, nil
+	}
+	return c.TransformFunc(ctx, file, v)
+}
+
+func (c *Category) Persist(ctx *ParseContext, rows []interface{}) error {
+	if c.PersistFunc != nil {
+		return c.PersistFunc(ctx, rows)
+	}
+	return BulkInsertByModel(ctx.Sink, rows)
+}
+
+// Finish uploads the media files, their keys keep the paths in the archive.
+func (c *Category) Finish(ctx *ParseContext, fs afero.Fs, dir string) error {
+	if !c.Media {
+		return nil
+	}
+	return storage.UploadFsExcept(fs, ctx.Store, path.Join(ctx.MediaKeyPrefix, path.Dir(c.Files.Location)), dir, c.Files.Regexp)
+}
+
+// BulkInsertByModel inserts rows of different models. The rows of each model are
+// inserted in one go, in the order that the models first appear in rows.
+func BulkInsertByModel(sink Sink, rows []interface{}) error {
+	models := make([]reflect.Type, 0)
+	groups := make(map[reflect.Type][]interface{})
+	for _, row := range rows {
+		t := reflect.TypeOf(row)
+		if _, ok := groups[t]; !ok {
+			models = append(models, t)
+		}
+		groups[t] = append(groups[t], row)
+	}
+
+	for _, t := range models {
+		if err := sink.BulkInsert(groups[t]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// BulkInsertInOrder is like BulkInsertByModel, but the rows of models are inserted first in the order
+// of models, so that the rows referred to by others are inserted before them wherever they appear in rows.
+func BulkInsertInOrder(sink Sink, models []interface{}, rows []interface{}) error {
+	ordered := make([]interface{}, 0, len(rows))
+	for _, m := range models {
+		for _, row := range rows {
+			if reflect.TypeOf(row) == reflect.TypeOf(m) {
+				ordered = append(ordered, row)
+			}
+		}
+	}
+	for _, row := range rows {
+		if !containsModel(models, row) {
+			ordered = append(ordered, row)
+		}
+	}
+	return BulkInsertByModel(sink, ordered)
+}
+
+func containsModel(models []interface{}, row interface{}) bool {
+	for _, m := range models {
+		if reflect.TypeOf(row) == reflect.TypeOf(m) {
+			return true
+		}
+	}
+	return false
+}
+
+// Registry keeps the handlers of a source in the order that they run.
+// A handler runs after the handlers whose rows it refers to.
+type Registry struct {
+	handlers []Handler
+}
+
+func (r *Registry) Register(h Handler) {
+	r.handlers = append(r.handlers, h)
+}
+
+// Handlers returns the handlers of the names, or all handlers if no names are given.
+func (r *Registry) Handlers(names ...string) ([]Handler, error) {
+	if len(names) == 0 {
+		return r.handlers, nil
+	}
+
+	wanted := make(map[string]bool)
+	for _, name := range names {
+		wanted[name] = true
+	}
+	result := make([]Handler, 0)
+	for _, h := range r.handlers {
+		if wanted[h.Pattern().Name] {
+			result = append(result, h)
+			delete(wanted, h.Pattern().Name)
+		}
+	}
+	for name := range wanted {
+		return nil, fmt.Errorf("unknown pattern: %s", name)
+	}
+	return result, nil
+}
+
+// CheckSubset returns an error unless the handlers can re-parse an archive without the others.
+// Rows of a model are deleted for the whole archive before it is re-parsed, so handlers sharing a model
+// must run together, and so must the handlers referring to the rows or state of one another.
+func (r *Registry) CheckSubset(handlers []Handler) error {
+	selected := make(map[string]bool)
+	names := make([]string, 0, len(handlers))
+	for _, h := range handlers {
+		selected[h.Pattern().Name] = true
+		names = append(names, h.Pattern().Name)
+	}
+
+	missing := make([]string, 0)
+	for found := true; found; {
+		found = false
+		for _, h := range r.handlers {
+			if selected[h.Pattern().Name] {
+				continue
+			}
+			for _, s := range r.handlers {
+				if selected[s.Pattern().Name] && related(h, s) {
+					selected[h.Pattern().Name] = true
+					found = true
+					break
+				}
+			}
+		}
+	}
+	for _, h := range r.handlers {
+		if selected[h.Pattern().Name] && !contains(names, h.Pattern().Name) {
+			missing = append(missing, h.Pattern().Name)
+		}
+	}
+
+	if len(missing) > 0 {
+		return fmt.Errorf("patterns %s must be parsed along with %s", strings.Join(names, ","), strings.Join(missing, ","))
+	}
+	return nil
+}
+
+// related tells if the handlers share a model, or if either refers to the other.
+func related(a, b Handler) bool {
+	if contains(a.Requires(), b.Pattern().Name) || contains(b.Requires(), a.Pattern().Name) {
+		return true
+	}
+	for _, m := range a.Models() {
+		for _, n := range b.Models() {
+			if reflect.TypeOf(m) == reflect.TypeOf(n) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func contains(names []string, name string) bool {
+	for _, n := range names {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,95 @@
+package facebook
+
+import (
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+)
+
+type memorySink struct {
+	rows    []interface{}
+	batches int
+}
+
+func (s *memorySink) BulkInsert(rows []interface{}) error {
+	s.batches++
+	s.rows = append(s.rows, rows...)
+	return nil
+}
+
+func (s *memorySink) Create(row interface{}) error {
+	s.rows = append(s.rows, row)
+	return nil
+}
+
+func (s *memorySink) FriendIDs(dataOwner, archiveID string) (map[string]int, error) {
+	return map[string]int{}, nil
+}
+
+func TestRegistryHandlers(t *testing.T) {
+	all, err := Handlers.Handlers()
+	assert.NoError(t, err)
+	assert.Equal(t, "profile", all[0].Pattern().Name)
+
+	// handlers keep the registration order rather than the order of the names
+	subset, err := Handlers.Handlers("posts", "friends")
+	assert.NoError(t, err)
+	if assert.Len(t, subset, 2) {
+		assert.Equal(t, "friends", subset[0].Pattern().Name)
+		assert.Equal(t, "posts", subset[1].Pattern().Name)
+	}
+
+	_, err = Handlers.Handlers("friends", "unknown")
+	assert.EqualError(t, err, "unknown pattern: unknown")
+}
+
+func TestRegistryCheckSubset(t *testing.T) {
+	all, err := Handlers.Handlers()
+	assert.NoError(t, err)
+	assert.NoError(t, Handlers.CheckSubset(all))
+
+	subset, err := Handlers.Handlers("ad_interests")
+	assert.NoError(t, err)
+	assert.NoError(t, Handlers.CheckSubset(subset))
+
+	// places are shared with the locations, and tags refer to friends, which friendship events refer to as well
+	subset, err = Handlers.Handlers("posts")
+	assert.NoError(t, err)
+	assert.EqualError(t, Handlers.CheckSubset(subset), "patterns posts must be parsed along with friends,friendship_events,location_history,primary_location")
+
+	subset, err = Handlers.Handlers("followers")
+	assert.NoError(t, err)
+	assert.EqualError(t, Handlers.CheckSubset(subset), "patterns followers must be parsed along with following,followed_pages")
+
+	subset, err = Handlers.Handlers("comments")
+	assert.NoError(t, err)
+	assert.EqualError(t, Handlers.CheckSubset(subset), "patterns comments must be parsed along with group_membership,group_activities")
+
+	subset, err = Handlers.Handlers("followers", "following", "followed_pages")
+	assert.NoError(t, err)
+	assert.NoError(t, Handlers.CheckSubset(subset))
+}
+
+func TestCategoryInIsolation(t *testing.T) {
+	sink := &memorySink{}
+	ctx := NewParseContext(&sequence{}, "owner", "archive", sink, nil, "owner/fb_archives/archive")
+
+	subset, err := Handlers.Handlers("ad_interests")
+	assert.NoError(t, err)
+	h := subset[0]
+
+	data := []byte(`{"topics": ["Go", "Rust"]}`)
+	assert.NoError(t, h.Pattern().Validate(data))
+	v, err := h.Decode(data)
+	assert.NoError(t, err)
+	rows, err := h.Transform(ctx, "ads_interests.json", v)
+	assert.NoError(t, err)
+	assert.NoError(t, h.Persist(ctx, rows))
+	assert.NoError(t, h.Finish(ctx, afero.NewMemMapFs(), "ads_and_businesses"))
+
+	assert.Equal(t, []interface{}{
+		AdInterestORM{Topic: "Go", DataOwnerID: "owner", ArchiveID: "archive"},
+		AdInterestORM{Topic: "Rust", DataOwnerID: "owner", ArchiveID: "archive"},
+	}, sink.rows)
+}
@@ -3,9 +3,12 @@ package facebook
 import (
 	"fmt"
 	"time"
+
+	"github.com/alecthomas/jsonschema"
+	"github.com/xeipuuv/gojsonschema"
 )
 
-func weekdayOfTime(t time.Time) int {
+func WeekdayOfTime(t time.Time) int {
 	weekday := t.Weekday() // time.Time Sunday is 0, this project Monday is 0
 
 	if weekday == time.Sunday {
@@ -16,11 +19,40 @@ func weekdayOfTime(t time.Time) int {
 }
 
 // 1999-01-01
-func dateOfTime(t time.Time) string {
+func DateOfTime(t time.Time) string {
 	return fmt.Sprintf("%d-%d-%d", t.Year(), t.Month(), t.Day())
 }
 
-// timestamp + id, id starts from 0
-func tableForeignKey(timestamp int64, offset int) int64 {
-	return timestamp*1000000 + int64(offset)
+// IDGenerator allocates the ids of parsed rows.
+// Ids must be unique across tasks and workers.
+type IDGenerator interface {
+	NextID() int64
+}
+
+var reflector = jsonschema.Reflector{
+	AllowAdditionalProperties:  false,
+	ExpandedStruct:             true,
+	RequiredFromJSONSchemaTags: true,
+}
+
+// SchemaLoaderOf reflects the JSON schema of v, additional properties are not allowed.
+func SchemaLoaderOf(v interface{}) *gojsonschema.Schema {
+	return schemaOf(reflector.Reflect(v))
+}
+
+// ArraySchemaLoaderOf reflects the JSON schema of an array of v, since some files are arrays at the top level.
+func ArraySchemaLoaderOf(v interface{}) *gojsonschema.Schema {
+	itemSchema := reflector.Reflect(v)
+	return schemaOf(&jsonschema.Schema{Type: &jsonschema.Type{
+		Version: jsonschema.Version,
+		Type:    "array",
+		Items:   itemSchema.Type,
+	}, Definitions: itemSchema.Definitions})
+}
+
+func schemaOf(s *jsonschema.Schema) *gojsonschema.Schema {
+	data, _ := s.MarshalJSON()
+	schemaLoader := gojsonschema.NewStringLoader(string(data))
+	schema, _ := gojsonschema.NewSchema(schemaLoader)
+	return schema
 }
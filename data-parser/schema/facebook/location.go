@@ -0,0 +1,139 @@
+package facebook
+
+import (
+	"strings"
+	"time"
+
+	"github.com/xeipuuv/gojsonschema"
+)
+
+const (
+	PlaceSourceCheckIn         = "check_in"
+	PlaceSourceLocationHistory = "location_history"
+	PlaceSourcePrimaryLocation = "primary_location"
+)
+
+type RawLocationHistory struct {
+	LocationHistory []*LocationHistoryEntry `json:"location_history" jsonschema:"required"`
+}
+
+type LocationHistoryEntry struct {
+	Name              MojibakeString `json:"name" jsonschema:"required"`
+	Coordinate        *Coordinate    `json:"coordinate" jsonschema:"required"`
+	CreationTimestamp int            `json:"creation_timestamp" jsonschema:"required"`
+}
+
+type RawPrimaryLocation struct {
+	PrimaryLocation *PrimaryLocation `json:"primary_location" jsonschema:"required"`
+}
+
+type PrimaryLocation struct {
+	// each pair is a city and its region
+	CityRegionPairs [][]MojibakeString `json:"city_region_pairs"`
+	Zipcode         []MojibakeString   `json:"zipcode"`
+}
+
+func LocationHistorySchemaLoader() *gojsonschema.Schema {
+	return SchemaLoaderOf(&RawLocationHistory{})
+}
+
+func PrimaryLocationSchemaLoader() *gojsonschema.Schema {
+	return SchemaLoaderOf(&RawPrimaryLocation{})
+}
+
+func (r RawLocationHistory) ORM(ids IDGenerator, owner, archiveID string) []Place {
+	result := make([]Place, 0)
+	for _, l := range r.LocationHistory {
+		t := time.Unix(int64(l.CreationTimestamp), 0)
+		result = append(result, Place{
+			PPID:        ids.NextID(),
+			Name:        string(l.Name),
+			Latitude:    l.Coordinate.Latitude,
+			Longitude:   l.Coordinate.Longitude,
+			Timestamp:   l.CreationTimestamp,
+			Date:        DateOfTime(t),
+			Source:      PlaceSourceLocationHistory,
+			DataOwnerID: owner,
+			ArchiveID:   archiveID,
+		})
+	}
+	return result
+}
+
+// ORM returns the cities of the primary location, which have neither coordinates nor timestamps.
+func (r RawPrimaryLocation) ORM(ids IDGenerator, owner, archiveID string) []Place {
+	result := make([]Place, 0)
+	if r.PrimaryLocation == nil {
+		return result
+	}
+
+	zipcode := ""
+	if len(r.PrimaryLocation.Zipcode) > 0 {
+		zipcode = string(r.PrimaryLocation.Zipcode[0])
+	}
+	for _, pair := range r.PrimaryLocation.CityRegionPairs {
+		if len(pair) == 0 {
+			continue
+		}
+		address := make([]string, 0)
+		for _, s := range pair {
+			address = append(address, string(s))
+		}
+		if zipcode != "" {
+			address = append(address, zipcode)
+		}
+		result = append(result, Place{
+			PPID:        ids.NextID(),
+			Name:        string(pair[0]),
+			Address:     strings.Join(address, ", "),
+			Source:      PlaceSourcePrimaryLocation,
+			DataOwnerID: owner,
+			ArchiveID:   archiveID,
+		})
+	}
+	return result
+}
+
+// placeVisitWindow is how far apart in seconds the records of a visit to a place can be. A check-in
+// and the location history point recorded along with it are a few minutes apart at most.
+const placeVisitWindow = 60 * 60
+
+type placeKey struct {
+	name      string
+	latitude  float64
+	longitude float64
+}
+
+// PlaceTimeline deduplicates the places parsed from the check-ins and location files of an archive.
+// Places of the same name and coordinates within placeVisitWindow are the same visit, while repeat visits are kept.
+type PlaceTimeline struct {
+	// the timestamps of the visits to each place
+	visits map[placeKey][]int
+}
+
+func NewPlaceTimeline() *PlaceTimeline {
+	return &PlaceTimeline{visits: make(map[placeKey][]int)}
+}
+
+// Add records the place and returns false if the visit to the place has been added.
+func (t *PlaceTimeline) Add(p Place) bool {
+	key := placeKey{p.Name, p.Latitude, p.Longitude}
+	for _, timestamp := range t.visits[key] {
+		if d := p.Timestamp - timestamp; d >= -placeVisitWindow && d <= placeVisitWindow {
+			return false
+		}
+	}
+	t.visits[key] = append(t.visits[key], p.Timestamp)
+	return true
+}
+
+// Dedup returns the places not added before, they are added as well.
+func (t *PlaceTimeline) Dedup(places []Place) []interface{} {
+	result := make([]interface{}, 0)
+	for _, p := range places {
+		if t.Add(p) {
+			result = append(result, p)
+		}
+	}
+	return result
+}
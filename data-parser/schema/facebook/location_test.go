@@ -0,0 +1,42 @@
+package facebook
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPlaceTimelineDedup(t *testing.T) {
+	timeline := NewPlaceTimeline()
+
+	checkIn := Place{Name: "Cafe", Latitude: 25.03, Longitude: 121.56, Timestamp: 1578201080, Source: PlaceSourceCheckIn}
+	assert.True(t, timeline.Add(checkIn))
+
+	places := timeline.Dedup([]Place{
+		{Name: "Cafe", Latitude: 25.03, Longitude: 121.56, Timestamp: 1578201080, Source: PlaceSourceLocationHistory},
+		{Name: "Cafe", Latitude: 25.04, Longitude: 121.56, Timestamp: 1578201090, Source: PlaceSourceLocationHistory},
+		{Name: "Park", Latitude: 25.04, Longitude: 121.57, Timestamp: 1578301080, Source: PlaceSourceLocationHistory},
+		{Name: "Park", Latitude: 25.04, Longitude: 121.57, Timestamp: 1578401080, Source: PlaceSourceLocationHistory},
+		{Name: "Park", Latitude: 25.04, Longitude: 121.57, Timestamp: 1578401080, Source: PlaceSourceLocationHistory},
+	})
+	// the check-in and the duplicate visit are left out, while the repeat visit to the park is kept
+	assert.Len(t, places, 3)
+	assert.Equal(t, 25.04, places[0].(Place).Latitude)
+	assert.Equal(t, "Park", places[1].(Place).Name)
+	assert.Equal(t, 1578301080, places[1].(Place).Timestamp)
+	assert.Equal(t, "Park", places[2].(Place).Name)
+	assert.Equal(t, 1578401080, places[2].(Place).Timestamp)
+}
+
+func TestPlaceTimelineCollapsesVisit(t *testing.T) {
+	timeline := NewPlaceTimeline()
+
+	// the location history records the check-in a few minutes later
+	assert.True(t, timeline.Add(Place{Name: "Cafe", Latitude: 25.03, Longitude: 121.56, Timestamp: 1578201080, Source: PlaceSourceCheckIn}))
+	assert.False(t, timeline.Add(Place{Name: "Cafe", Latitude: 25.03, Longitude: 121.56, Timestamp: 1578201380, Source: PlaceSourceLocationHistory}))
+	// or a bit earlier
+	assert.False(t, timeline.Add(Place{Name: "Cafe", Latitude: 25.03, Longitude: 121.56, Timestamp: 1578200900, Source: PlaceSourceLocationHistory}))
+
+	// another visit on the next day
+	assert.True(t, timeline.Add(Place{Name: "Cafe", Latitude: 25.03, Longitude: 121.56, Timestamp: 1578287480, Source: PlaceSourceLocationHistory}))
+}
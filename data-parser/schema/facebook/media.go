@@ -0,0 +1,196 @@
+package facebook
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/xeipuuv/gojsonschema"
+)
+
+const (
+	MediaTypePhoto = "photo"
+	MediaTypeVideo = "video"
+)
+
+// RawMediaFile is either an album file in photos_and_videos/album or photos_and_videos/your_videos.json.
+type RawMediaFile struct {
+	Name                  MojibakeString `json:"name"`
+	Description           MojibakeString `json:"description"`
+	LastModifiedTimestamp int            `json:"last_modified_timestamp"`
+	CoverPhoto            *Media         `json:"cover_photo"`
+	Photos                []*Media       `json:"photos"`
+	Videos                []*Media       `json:"videos"`
+}
+
+func MediaSchemaLoader() *gojsonschema.Schema {
+	return SchemaLoaderOf(&RawMediaFile{})
+}
+
+type AlbumORM struct {
+	AlbumID               int64
+	Name                  string
+	Description           string
+	LastModifiedTimestamp int
+	CoverPhotoURI         string
+	DataOwnerID           string
+	ArchiveID             string
+}
+
+func (AlbumORM) TableName() string {
+	return "media_album"
+}
+
+// MediaItemORM is a photo or video, its media uri is the key of the uploaded file.
+type MediaItemORM struct {
+	MediaID           int64
+	AlbumID           *int64
+	Type              string
+	MediaURI          string
+	FilenameExtension string
+	ThumbnailURI      string
+	Title             string
+	Description       string
+	CreationTimestamp int
+	DataOwnerID       string
+	ArchiveID         string
+}
+
+func (MediaItemORM) TableName() string {
+	return "media_mediaitem"
+}
+
+type MediaMetadataORM struct {
+	MediaID           int64
+	CameraMake        string
+	CameraModel       string
+	TakenTimestamp    int
+	ModifiedTimestamp int
+	Exposure          string
+	FocalLength       string
+	FStop             string
+	ISOSpeed          int
+	Latitude          float64
+	Longitude         float64
+	Orientation       float64
+	OriginalWidth     int
+	OriginalHeight    int
+	UploadIP          string
+	UploadTimestamp   int
+	DataOwnerID       string
+	ArchiveID         string
+}
+
+func (MediaMetadataORM) TableName() string {
+	return "media_metadata"
+}
+
+type MediaCommentORM struct {
+	MediaID     int64
+	Comment     string
+	Author      string
+	Group       string
+	Timestamp   int64
+	DataOwnerID string
+	ArchiveID   string
+}
+
+func (MediaCommentORM) TableName() string {
+	return "media_comment"
+}
+
+// MediaKey is the object key of a media file, whose uri is its path in the archive.
+func MediaKey(owner, archiveID string, uri MojibakeString) string {
+	return fmt.Sprintf("%s/fb_archives/%s/%s", owner, archiveID, string(uri))
+}
+
+// ORM returns the album, if the file is an album, followed by the media items with their metadata and comments.
+func (r RawMediaFile) ORM(ids IDGenerator, owner, archiveID string) []interface{} {
+	result := make([]interface{}, 0)
+
+	var albumID *int64
+	if r.Photos != nil {
+		id := ids.NextID()
+		albumID = &id
+		album := AlbumORM{
+			AlbumID:               id,
+			Name:                  string(r.Name),
+			Description:           string(r.Description),
+			LastModifiedTimestamp: r.LastModifiedTimestamp,
+			DataOwnerID:           owner,
+			ArchiveID:             archiveID,
+		}
+		if r.CoverPhoto != nil {
+			album.CoverPhotoURI = MediaKey(owner, archiveID, r.CoverPhoto.URI)
+		}
+		result = append(result, album)
+	}
+
+	for _, m := range r.Photos {
+		result = append(result, mediaItemORM(ids, m, MediaTypePhoto, albumID, owner, archiveID)...)
+	}
+	for _, m := range r.Videos {
+		result = append(result, mediaItemORM(ids, m, MediaTypeVideo, albumID, owner, archiveID)...)
+	}
+	return result
+}
+
+func mediaItemORM(ids IDGenerator, m *Media, mediaType string, albumID *int64, owner, archiveID string) []interface{} {
+	item := MediaItemORM{
+		MediaID:           ids.NextID(),
+		AlbumID:           albumID,
+		Type:              mediaType,
+		MediaURI:          MediaKey(owner, archiveID, m.URI),
+		FilenameExtension: filepath.Ext(string(m.URI)),
+		Title:             string(m.Title),
+		Description:       string(m.Description),
+		CreationTimestamp: m.CreationTimestamp,
+		DataOwnerID:       owner,
+		ArchiveID:         archiveID,
+	}
+	if m.Thumbnail != nil {
+		item.ThumbnailURI = MediaKey(owner, archiveID, m.Thumbnail.URI)
+	}
+	result := []interface{}{item}
+
+	if m.MediaMetadata != nil {
+		metadata := MediaMetadataORM{
+			MediaID:     item.MediaID,
+			DataOwnerID: owner,
+			ArchiveID:   archiveID,
+		}
+		if p := m.MediaMetadata.PhotoMetadata; p != nil {
+			metadata.CameraMake = string(p.CameraMake)
+			metadata.CameraModel = string(p.CameraModel)
+			metadata.TakenTimestamp = p.TakenTimestamp
+			metadata.ModifiedTimestamp = p.ModifiedTimestamp
+			metadata.Exposure = string(p.Exposure)
+			metadata.FocalLength = string(p.FocalLength)
+			metadata.FStop = string(p.FStop)
+			metadata.ISOSpeed = p.ISOSpeed
+			metadata.Latitude = p.Latitude
+			metadata.Longitude = p.Longitude
+			metadata.Orientation = p.Orientation
+			metadata.OriginalWidth = p.OriginalWidth
+			metadata.OriginalHeight = p.OriginalHeight
+			metadata.UploadIP = string(p.UploadIP)
+		}
+		if v := m.MediaMetadata.VidoMetadata; v != nil {
+			metadata.UploadIP = string(v.UploadIP)
+			metadata.UploadTimestamp = v.UploadTimestamp
+		}
+		result = append(result, metadata)
+	}
+
+	for _, c := range m.Commens {
+		result = append(result, MediaCommentORM{
+			MediaID:     item.MediaID,
+			Comment:     string(c.Comment),
+			Author:      string(c.Author),
+			Group:       string(c.Group),
+			Timestamp:   c.Timestamp,
+			DataOwnerID: owner,
+			ArchiveID:   archiveID,
+		})
+	}
+	return result
+}
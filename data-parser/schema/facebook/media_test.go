@@ -0,0 +1,103 @@
+package facebook
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAlbumORM(t *testing.T) {
+	data := []byte(`{
+		"name":"Trip","description":"CafÃ©s","last_modified_timestamp":1578201100,
+		"cover_photo":{"uri":"photos_and_videos/trip_a1b2/a.jpg"},
+		"photos":[{
+			"uri":"photos_and_videos/trip_a1b2/a.jpg","creation_timestamp":1578201080,"title":"Trip",
+			"media_metadata":{"photo_metadata":{"camera_make":"Apple","camera_model":"iPhone X","taken_timestamp":1578201000,"iso_speed":100,"latitude":25.03,"longitude":121.56,"upload_ip":"1.2.3.4"}},
+			"comments":[{"comment":"wow","timestamp":1578201090,"author":"Alice"}]
+		}]
+	}`)
+	assert.NoError(t, MediaPattern.Validate(data))
+
+	var raw RawMediaFile
+	assert.NoError(t, json.Unmarshal(data, &raw))
+	albumID := int64(1)
+	assert.Equal(t, []interface{}{
+		AlbumORM{
+			AlbumID:               1,
+			Name:                  "Trip",
+			Description:           "Cafés",
+			LastModifiedTimestamp: 1578201100,
+			CoverPhotoURI:         MediaKey("owner", "archive", "photos_and_videos/trip_a1b2/a.jpg"),
+			DataOwnerID:           "owner",
+			ArchiveID:             "archive",
+		},
+		MediaItemORM{
+			MediaID:           2,
+			AlbumID:           &albumID,
+			Type:              MediaTypePhoto,
+			MediaURI:          "owner/fb_archives/archive/photos_and_videos/trip_a1b2/a.jpg",
+			FilenameExtension: ".jpg",
+			Title:             "Trip",
+			CreationTimestamp: 1578201080,
+			DataOwnerID:       "owner",
+			ArchiveID:         "archive",
+		},
+		MediaMetadataORM{
+			MediaID:        2,
+			CameraMake:     "Apple",
+			CameraModel:    "iPhone X",
+			TakenTimestamp: 1578201000,
+			ISOSpeed:       100,
+			Latitude:       25.03,
+			Longitude:      121.56,
+			UploadIP:       "1.2.3.4",
+			DataOwnerID:    "owner",
+			ArchiveID:      "archive",
+		},
+		MediaCommentORM{MediaID: 2, Comment: "wow", Author: "Alice", Timestamp: 1578201090, DataOwnerID: "owner", ArchiveID: "archive"},
+	}, raw.ORM(&sequence{}, "owner", "archive"))
+}
+
+func TestVideosORM(t *testing.T) {
+	data := []byte(`{"videos":[{
+		"uri":"photos_and_videos/videos/v.mp4","creation_timestamp":1578201080,
+		"thumbnail":{"uri":"photos_and_videos/thumbnails/v.jpg"},
+		"media_metadata":{"video_metadata":{"upload_ip":"5.6.7.8","upload_timestamp":1578201070}}
+	}]}`)
+	assert.NoError(t, MediaPattern.Validate(data))
+
+	// your_videos.json isn't an album
+	var raw RawMediaFile
+	assert.NoError(t, json.Unmarshal(data, &raw))
+	assert.Equal(t, []interface{}{
+		MediaItemORM{
+			MediaID:           1,
+			Type:              MediaTypeVideo,
+			MediaURI:          MediaKey("owner", "archive", "photos_and_videos/videos/v.mp4"),
+			FilenameExtension: ".mp4",
+			ThumbnailURI:      "owner/fb_archives/archive/photos_and_videos/thumbnails/v.jpg",
+			CreationTimestamp: 1578201080,
+			DataOwnerID:       "owner",
+			ArchiveID:         "archive",
+		},
+		MediaMetadataORM{MediaID: 1, UploadIP: "5.6.7.8", UploadTimestamp: 1578201070, DataOwnerID: "owner", ArchiveID: "archive"},
+	}, raw.ORM(&sequence{}, "owner", "archive"))
+}
+
+func TestMediaMetadataORM(t *testing.T) {
+	var m Media
+	assert.NoError(t, json.Unmarshal([]byte(`{"uri":"photos_and_videos/trip_a1b2/v.mp4","media_metadata":{
+		"photo_metadata":{"camera_make":"Apple","upload_ip":"1.2.3.4"},
+		"video_metadata":{"upload_ip":"5.6.7.8","upload_timestamp":1578201070}
+	}}`), &m))
+
+	// the upload of a video with photo metadata is told by the video metadata
+	rows := mediaItemORM(&sequence{}, &m, MediaTypeVideo, nil, "owner", "archive")
+	if assert.Len(t, rows, 2) {
+		metadata := rows[1].(MediaMetadataORM)
+		assert.Equal(t, "Apple", metadata.CameraMake)
+		assert.Equal(t, "5.6.7.8", metadata.UploadIP)
+		assert.Equal(t, 1578201070, metadata.UploadTimestamp)
+	}
+}
@@ -0,0 +1,227 @@
+package facebook
+
+import (
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/xeipuuv/gojsonschema"
+)
+
+type RawConversation struct {
+	Participants       []*Participant `json:"participants" jsonschema:"required"`
+	Messages           []*Message     `json:"messages" jsonschema:"required"`
+	Title              MojibakeString `json:"title"`
+	IsStillParticipant bool           `json:"is_still_participant"`
+	ThreadType         string         `json:"thread_type"`
+	ThreadPath         string         `json:"thread_path"`
+}
+
+type Participant struct {
+	Name MojibakeString `json:"name" jsonschema:"required"`
+}
+
+type Message struct {
+	SenderName   MojibakeString     `json:"sender_name" jsonschema:"required"`
+	TimestampMS  int64              `json:"timestamp_ms" jsonschema:"required"`
+	Type         string             `json:"type" jsonschema:"required"`
+	Content      MojibakeString     `json:"content"`
+	Photos       []*MessageMedia    `json:"photos"`
+	Videos       []*MessageMedia    `json:"videos"`
+	AudioFiles   []*MessageMedia    `json:"audio_files"`
+	Files        []*MessageMedia    `json:"files"`
+	Gifs         []*MessageMedia    `json:"gifs"`
+	Sticker      *MessageSticker    `json:"sticker"`
+	Share        *MessageShare      `json:"share"`
+	Reactions    []*MessageReaction `json:"reactions"`
+	Users        []*Participant     `json:"users"`
+	CallDuration int                `json:"call_duration"`
+	Missed       bool               `json:"missed"`
+}
+
+type MessageMedia struct {
+	URI               MojibakeString  `json:"uri" jsonschema:"required"`
+	CreationTimestamp int             `json:"creation_timestamp"`
+	Thumbnail         *MediaThumbnail `json:"thumbnail"`
+}
+
+type MessageSticker struct {
+	URI MojibakeString `json:"uri" jsonschema:"required"`
+}
+
+type MessageShare struct {
+	Link      MojibakeString `json:"link"`
+	ShareText MojibakeString `json:"share_text"`
+}
+
+type MessageReaction struct {
+	Reaction MojibakeString `json:"reaction" jsonschema:"required"`
+	Actor    MojibakeString `json:"actor" jsonschema:"required"`
+}
+
+func ConversationSchemaLoader() *gojsonschema.Schema {
+	return SchemaLoaderOf(&RawConversation{})
+}
+
+type ConversationORM struct {
+	ConversationID     int64
+	Title              string
+	ThreadType         string
+	ThreadPath         string
+	IsStillParticipant bool
+	DataOwnerID        string
+	ArchiveID          string
+}
+
+func (ConversationORM) TableName() string {
+	return "messages_conversation"
+}
+
+type ParticipantORM struct {
+	ConversationID int64
+	Name           string
+	DataOwnerID    string
+	ArchiveID      string
+}
+
+func (ParticipantORM) TableName() string {
+	return "messages_participant"
+}
+
+type MessageORM struct {
+	MessageID      int64
+	ConversationID int64
+	SenderName     string
+	TimestampMS    int64 `gorm:"column:timestamp_ms"`
+	Date           string
+	Weekday        int
+	Type           string
+	Content        string
+	StickerURI     string
+	ShareLink      string
+	ShareText      string
+	CallDuration   int
+	Missed         bool
+	DataOwnerID    string
+	ArchiveID      string
+}
+
+func (MessageORM) TableName() string {
+	return "messages_message"
+}
+
+type MessageAttachmentORM struct {
+	AttachmentID      int64
+	MessageID         int64
+	Type              string
+	MediaURI          string
+	FilenameExtension string
+	CreationTimestamp int
+	DataOwnerID       string
+	ArchiveID         string
+}
+
+func (MessageAttachmentORM) TableName() string {
+	return "messages_attachment"
+}
+
+type MessageReactionORM struct {
+	MessageID   int64
+	Reaction    string
+	Actor       string
+	DataOwnerID string
+	ArchiveID   string
+}
+
+func (MessageReactionORM) TableName() string {
+	return "messages_reaction"
+}
+
+// ConversationORM returns the conversation and its participants.
+// A long conversation is split into several files, only one of them should be used.
+func (c RawConversation) ConversationORM(conversationID int64, owner, archiveID string) []interface{} {
+	result := []interface{}{
+		ConversationORM{
+			ConversationID:     conversationID,
+			Title:              string(c.Title),
+			ThreadType:         c.ThreadType,
+			ThreadPath:         c.ThreadPath,
+			IsStillParticipant: c.IsStillParticipant,
+			DataOwnerID:        owner,
+			ArchiveID:          archiveID,
+		},
+	}
+	for _, p := range c.Participants {
+		result = append(result, ParticipantORM{
+			ConversationID: conversationID,
+			Name:           string(p.Name),
+			DataOwnerID:    owner,
+			ArchiveID:      archiveID,
+		})
+	}
+	return result
+}
+
+// MessagesORM returns the messages of the conversation along with their attachments and reactions.
+// The media uri of an attachment is the key of the file uploaded with the messages.
+func (c RawConversation) MessagesORM(ids IDGenerator, conversationID int64, owner, archiveID string) []interface{} {
+	result := make([]interface{}, 0)
+	for _, m := range c.Messages {
+		t := time.Unix(0, m.TimestampMS*int64(time.Millisecond))
+		orm := MessageORM{
+			MessageID:      ids.NextID(),
+			ConversationID: conversationID,
+			SenderName:     string(m.SenderName),
+			TimestampMS:    m.TimestampMS,
+			Date:           DateOfTime(t),
+			Weekday:        WeekdayOfTime(t),
+			Type:           m.Type,
+			Content:        string(m.Content),
+			CallDuration:   m.CallDuration,
+			Missed:         m.Missed,
+			DataOwnerID:    owner,
+			ArchiveID:      archiveID,
+		}
+		if m.Sticker != nil {
+			orm.StickerURI = string(m.Sticker.URI)
+		}
+		if m.Share != nil {
+			orm.ShareLink = string(m.Share.Link)
+			orm.ShareText = string(m.Share.ShareText)
+		}
+		result = append(result, orm)
+
+		attachments := map[string][]*MessageMedia{
+			"photo": m.Photos,
+			"video": m.Videos,
+			"audio": m.AudioFiles,
+			"file":  m.Files,
+			"gif":   m.Gifs,
+		}
+		for _, attachmentType := range []string{"photo", "video", "audio", "file", "gif"} {
+			for _, media := range attachments[attachmentType] {
+				result = append(result, MessageAttachmentORM{
+					AttachmentID:      ids.NextID(),
+					MessageID:         orm.MessageID,
+					Type:              attachmentType,
+					MediaURI:          fmt.Sprintf("%s/fb_archives/%s/%s", owner, archiveID, string(media.URI)),
+					FilenameExtension: filepath.Ext(string(media.URI)),
+					CreationTimestamp: media.CreationTimestamp,
+					DataOwnerID:       owner,
+					ArchiveID:         archiveID,
+				})
+			}
+		}
+
+		for _, r := range m.Reactions {
+			result = append(result, MessageReactionORM{
+				MessageID:   orm.MessageID,
+				Reaction:    string(r.Reaction),
+				Actor:       string(r.Actor),
+				DataOwnerID: owner,
+				ArchiveID:   archiveID,
+			})
+		}
+	}
+	return result
+}
@@ -0,0 +1,89 @@
+package facebook
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConversationORM(t *testing.T) {
+	var raw RawConversation
+	assert.NoError(t, json.Unmarshal([]byte(`{
+		"participants":[{"name":"JosÃ©"},{"name":"Me"}],
+		"messages":[],
+		"title":"JosÃ©","is_still_participant":true,"thread_type":"Regular","thread_path":"inbox/jose_a1b2"
+	}`), &raw))
+
+	assert.Equal(t, []interface{}{
+		ConversationORM{
+			ConversationID:     7,
+			Title:              "José",
+			ThreadType:         "Regular",
+			ThreadPath:         "inbox/jose_a1b2",
+			IsStillParticipant: true,
+			DataOwnerID:        "owner",
+			ArchiveID:          "archive",
+		},
+		ParticipantORM{ConversationID: 7, Name: "José", DataOwnerID: "owner", ArchiveID: "archive"},
+		ParticipantORM{ConversationID: 7, Name: "Me", DataOwnerID: "owner", ArchiveID: "archive"},
+	}, raw.ConversationORM(7, "owner", "archive"))
+}
+
+func TestMessagesORM(t *testing.T) {
+	var raw RawConversation
+	assert.NoError(t, json.Unmarshal([]byte(`{
+		"participants":[{"name":"JosÃ©"},{"name":"Me"}],
+		"messages":[
+			{"sender_name":"JosÃ©","timestamp_ms":1578201080000,"type":"Generic","content":"CafÃ© tonight?",
+			 "photos":[{"uri":"messages/inbox/jose_a1b2/photos/1.jpg","creation_timestamp":1578201080}],
+			 "reactions":[{"reaction":"ð\u009f\u0098\u008d","actor":"Me"}]},
+			{"sender_name":"Me","timestamp_ms":1578201090000,"type":"Share","share":{"link":"https://example.com","share_text":"Menu"},
+			 "sticker":{"uri":"messages/stickers_used/1.png"}}
+		]
+	}`), &raw))
+
+	rows := raw.MessagesORM(&sequence{}, 7, "owner", "archive")
+	sent := time.Unix(1578201080, 0)
+	replied := time.Unix(1578201090, 0)
+	assert.Equal(t, []interface{}{
+		MessageORM{
+			MessageID:      1,
+			ConversationID: 7,
+			SenderName:     "José",
+			TimestampMS:    1578201080000,
+			Date:           DateOfTime(sent),
+			Weekday:        WeekdayOfTime(sent),
+			Type:           "Generic",
+			Content:        "Café tonight?",
+			DataOwnerID:    "owner",
+			ArchiveID:      "archive",
+		},
+		MessageAttachmentORM{
+			AttachmentID:      2,
+			MessageID:         1,
+			Type:              "photo",
+			MediaURI:          "owner/fb_archives/archive/messages/inbox/jose_a1b2/photos/1.jpg",
+			FilenameExtension: ".jpg",
+			CreationTimestamp: 1578201080,
+			DataOwnerID:       "owner",
+			ArchiveID:         "archive",
+		},
+		MessageReactionORM{MessageID: 1, Reaction: "😍", Actor: "Me", DataOwnerID: "owner", ArchiveID: "archive"},
+		MessageORM{
+			MessageID:      3,
+			ConversationID: 7,
+			SenderName:     "Me",
+			TimestampMS:    1578201090000,
+			Date:           DateOfTime(replied),
+			Weekday:        WeekdayOfTime(replied),
+			Type:           "Share",
+			StickerURI:     "messages/stickers_used/1.png",
+			ShareLink:      "https://example.com",
+			ShareText:      "Menu",
+			DataOwnerID:    "owner",
+			ArchiveID:      "archive",
+		},
+	}, rows)
+}
@@ -0,0 +1,42 @@
+package facebook
+
+import (
+	"github.com/xeipuuv/gojsonschema"
+)
+
+type RawPageLikes struct {
+	PageLikes []*PageLike `json:"page_likes" jsonschema:"required"`
+}
+
+type PageLike struct {
+	Name      MojibakeString `json:"name" jsonschema:"required"`
+	Timestamp int            `json:"timestamp" jsonschema:"required"`
+}
+
+func PageLikeSchemaLoader() *gojsonschema.Schema {
+	return SchemaLoaderOf(&RawPageLikes{})
+}
+
+type PageLikeORM struct {
+	Name        string
+	Timestamp   int
+	DataOwnerID string
+	ArchiveID   string
+}
+
+func (PageLikeORM) TableName() string {
+	return "pages_pagelike"
+}
+
+func (r RawPageLikes) ORM(owner, archiveID string) []interface{} {
+	result := make([]interface{}, 0)
+	for _, p := range r.PageLikes {
+		result = append(result, PageLikeORM{
+			Name:        string(p.Name),
+			Timestamp:   p.Timestamp,
+			DataOwnerID: owner,
+			ArchiveID:   archiveID,
+		})
+	}
+	return result
+}
@@ -3,6 +3,7 @@ package facebook
 import (
 	"errors"
 	"fmt"
+	"os"
 	"path/filepath"
 	"regexp"
 	"strings"
@@ -12,12 +13,39 @@ import (
 )
 
 var (
-	FriendsPattern   = Pattern{Name: "friends", Location: "friends", Regexp: regexp.MustCompile("^friends.json"), Schema: FriendSchemaLoader()}
-	PostsPattern     = Pattern{Name: "posts", Location: "posts", Regexp: regexp.MustCompile("your_posts(?P<index>_[0-9]+).json"), Schema: PostArraySchemaLoader()}
-	ReactionsPattern = Pattern{Name: "reactions", Location: "likes_and_reactions", Regexp: regexp.MustCompile("posts_and_comments.json"), Schema: ReactionSchemaLoader()}
-	CommentsPattern  = Pattern{Name: "comments", Location: "comments", Regexp: regexp.MustCompile("comments.json"), Schema: CommentArraySchemaLoader()}
-	MediaPattern     = Pattern{Name: "media", Location: "photos_and_videos"}
-	FilesPattern     = Pattern{Name: "files", Location: "files"}
+	FriendsPattern                = Pattern{Name: "friends", Location: "friends", Regexp: regexp.MustCompile("^friends.json"), Schema: FriendSchemaLoader(), Versions: []SchemaVersion{FriendsV2}}
+	FriendshipEventsPattern       = Pattern{Name: "friendship_events", Location: "friends", Regexp: regexp.MustCompile("^(sent_friend_requests|received_friend_requests|rejected_friend_requests|removed_friends).json$"), Schema: FriendshipEventSchemaLoader(), Versions: []SchemaVersion{FriendshipEventsV2}}
+	PostsPattern                  = Pattern{Name: "posts", Location: "posts", Regexp: regexp.MustCompile("your_posts(?P<index>_[0-9]+).json"), Schema: PostArraySchemaLoader()}
+	ReactionsPattern              = Pattern{Name: "reactions", Location: "likes_and_reactions", Regexp: regexp.MustCompile("posts_and_comments.json"), Schema: ReactionSchemaLoader()}
+	CommentsPattern               = Pattern{Name: "comments", Location: "comments", Regexp: regexp.MustCompile("comments.json"), Schema: CommentArraySchemaLoader(), Versions: []SchemaVersion{CommentsV2}}
+	ProfilePattern                = Pattern{Name: "profile", Location: "profile_information", Regexp: regexp.MustCompile("^profile_information.json$"), Schema: ProfileSchemaLoader()}
+	FriendPeerGroupPattern        = Pattern{Name: "friend_peer_group", Location: "about_you", Regexp: regexp.MustCompile("^friend_peer_group.json$"), Schema: FriendPeerGroupSchemaLoader()}
+	AdvertiserContactListsPattern = Pattern{Name: "advertiser_contact_lists", Location: "ads_and_businesses", Regexp: regexp.MustCompile("^advertisers_who_uploaded_a_contact_list_with_your_information.json$"), Schema: AdvertiserContactListSchemaLoader()}
+	AdInteractionsPattern         = Pattern{Name: "ad_interactions", Location: "ads_and_businesses", Regexp: regexp.MustCompile("^advertisers_you've_interacted_with.json$"), Schema: AdInteractionSchemaLoader()}
+	AdInterestsPattern            = Pattern{Name: "ad_interests", Location: "ads_and_businesses", Regexp: regexp.MustCompile("^ads_interests.json$"), Schema: AdInterestSchemaLoader()}
+	OffFacebookActivityPattern    = Pattern{Name: "off_facebook_activity", Location: "ads_and_businesses", Regexp: regexp.MustCompile("^your_off-facebook_activity.json$"), Schema: OffFacebookActivitySchemaLoader()}
+	LocationHistoryPattern        = Pattern{Name: "location_history", Location: "location_history", Regexp: regexp.MustCompile("^your_location_history.json$"), Schema: LocationHistorySchemaLoader()}
+	PrimaryLocationPattern        = Pattern{Name: "primary_location", Location: "location", Regexp: regexp.MustCompile("^primary_location.json$"), Schema: PrimaryLocationSchemaLoader()}
+	LoginsAndLogoutsPattern       = Pattern{Name: "logins_and_logouts", Location: "security_and_login_information", Regexp: regexp.MustCompile("^logins_and_logouts.json$"), Schema: LoginsAndLogoutsSchemaLoader()}
+	AccountActivityPattern        = Pattern{Name: "account_activity", Location: "security_and_login_information", Regexp: regexp.MustCompile("^account_activity.json$"), Schema: AccountActivitySchemaLoader()}
+	UsedIPAddressesPattern        = Pattern{Name: "used_ip_addresses", Location: "security_and_login_information", Regexp: regexp.MustCompile("^used_ip_addresses.json$"), Schema: UsedIPAddressSchemaLoader()}
+	ActiveSessionsPattern         = Pattern{Name: "active_sessions", Location: "security_and_login_information", Regexp: regexp.MustCompile("^where_you're_logged_in.json$"), Schema: ActiveSessionSchemaLoader()}
+	AdministrativeRecordsPattern  = Pattern{Name: "administrative_records", Location: "security_and_login_information", Regexp: regexp.MustCompile("^administrative_records.json$"), Schema: AdministrativeRecordSchemaLoader()}
+	GroupMembershipPattern        = Pattern{Name: "group_membership", Location: "groups", Regexp: regexp.MustCompile("^your_group_membership_activity.json$"), Schema: GroupMembershipSchemaLoader()}
+	GroupActivitiesPattern        = Pattern{Name: "group_activities", Location: "groups", Regexp: regexp.MustCompile("^your_posts_and_comments_in_groups.json$"), Schema: GroupActivitySchemaLoader()}
+	PageLikesPattern              = Pattern{Name: "page_likes", Location: "pages", Regexp: regexp.MustCompile("^pages_you've_liked.json$"), Schema: PageLikeSchemaLoader()}
+	EventResponsesPattern         = Pattern{Name: "event_responses", Location: "events", Regexp: regexp.MustCompile("^your_event_responses.json$"), Schema: EventResponseSchemaLoader()}
+	EventInvitationsPattern       = Pattern{Name: "event_invitations", Location: "events", Regexp: regexp.MustCompile("^event_invitations.json$"), Schema: EventInvitationSchemaLoader()}
+	FollowersPattern              = Pattern{Name: "followers", Location: "following_and_followers", Regexp: regexp.MustCompile("^followers.json$"), Schema: FollowerSchemaLoader()}
+	FollowingPattern              = Pattern{Name: "following", Location: "following_and_followers", Regexp: regexp.MustCompile("^following.json$"), Schema: FollowingSchemaLoader()}
+	FollowedPagesPattern          = Pattern{Name: "followed_pages", Location: "following_and_followers", Regexp: regexp.MustCompile("^followed_pages.json$"), Schema: FollowedPageSchemaLoader()}
+	TimelinePostsPattern          = Pattern{Name: "timeline_posts", Location: "posts", Regexp: regexp.MustCompile("^other_people's_posts_to_your_timeline.json$"), Schema: TimelinePostSchemaLoader()}
+	SearchHistoryPattern          = Pattern{Name: "search_history", Location: "search_history", Regexp: regexp.MustCompile("^your_search_history.json$"), Schema: SearchHistorySchemaLoader()}
+	SavedItemsPattern             = Pattern{Name: "saved_items", Location: "saved_items_and_collections", Regexp: regexp.MustCompile("^saved_items_and_collections.json$"), Schema: SavedItemSchemaLoader()}
+	StoriesPattern                = Pattern{Name: "stories", Location: "stories", Regexp: regexp.MustCompile("^(archived_stories|story_reactions).json$"), Schema: StorySchemaLoader()}
+	MessagesPattern               = Pattern{Name: "messages", Location: "messages/inbox", Regexp: regexp.MustCompile("^message_[0-9]+.json$"), Schema: ConversationSchemaLoader(), Recursive: true}
+	MediaPattern                  = Pattern{Name: "media", Location: "photos_and_videos", Regexp: regexp.MustCompile("^([0-9]+|your_videos).json$"), Schema: MediaSchemaLoader(), Recursive: true}
+	FilesPattern                  = Pattern{Name: "files", Location: "files"}
 )
 
 type Pattern struct {
@@ -25,6 +53,10 @@ type Pattern struct {
 	Location string
 	Regexp   *regexp.Regexp
 	Schema   *gojsonschema.Schema
+	// Recursive patterns select files in the sub-directories as well
+	Recursive bool
+	// Versions are the formats of the files other than the current one
+	Versions []SchemaVersion
 }
 
 func (p *Pattern) SelectFiles(fs afero.Fs, dirname string) ([]string, error) {
@@ -38,6 +70,34 @@ func (p *Pattern) SelectFiles(fs afero.Fs, dirname string) ([]string, error) {
 		return nil, nil
 	}
 
+	// the location of a pattern is a file if it is at the root of the archive
+	isDir, err := afero.IsDir(fs, dirname)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check if %s is a dir: %s", dirname, err)
+	}
+	if !isDir {
+		if p.Regexp.MatchString(filepath.Base(dirname)) {
+			targetedFiles = append(targetedFiles, dirname)
+		}
+		return targetedFiles, nil
+	}
+
+	if p.Recursive {
+		err := afero.Walk(fs, dirname, func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			if !info.IsDir() && p.Regexp.MatchString(info.Name()) {
+				targetedFiles = append(targetedFiles, path)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to walk dir %s: %s", dirname, err)
+		}
+		return targetedFiles, nil
+	}
+
 	files, err := afero.ReadDir(fs, dirname)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read dir %s: %s", dirname, err)
@@ -52,17 +112,34 @@ func (p *Pattern) SelectFiles(fs afero.Fs, dirname string) ([]string, error) {
 }
 
 func (p *Pattern) Validate(data []byte) error {
-	docLoader := gojsonschema.NewBytesLoader(data)
-	result, err := p.Schema.Validate(docLoader)
+	return validate(p.Schema, data)
+}
+
+func validate(schema *gojsonschema.Schema, data []byte) error {
+	reasons, err := validationReasons(schema, data)
 	if err != nil {
 		return err
 	}
-	if !result.Valid() {
-		reasons := make([]string, 0)
-		for _, desc := range result.Errors() {
-			reasons = append(reasons, desc.String())
-		}
+	if len(reasons) > 0 {
 		return errors.New(strings.Join(reasons, "\n"))
 	}
 	return nil
 }
+
+// validationReasons returns why data doesn't match the schema, or nil if it does.
+func validationReasons(schema *gojsonschema.Schema, data []byte) ([]string, error) {
+	docLoader := gojsonschema.NewBytesLoader(data)
+	result, err := schema.Validate(docLoader)
+	if err != nil {
+		return nil, err
+	}
+	return reasonsOf(result.Errors()), nil
+}
+
+func reasonsOf(errs []gojsonschema.ResultError) []string {
+	var reasons []string
+	for _, desc := range errs {
+		reasons = append(reasons, desc.String())
+	}
+	return reasons
+}
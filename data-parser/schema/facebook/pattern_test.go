@@ -1,6 +1,7 @@
 package facebook
 
 import (
+	"encoding/json"
 	"testing"
 
 	"github.com/spf13/afero"
@@ -44,3 +45,144 @@ func TestPostsPattern(t *testing.T) {
 	assert.Empty(t, filenames)
 	assert.NoError(t, err)
 }
+
+func TestMessagesPattern(t *testing.T) {
+	cases := map[string]testCase{
+		"/tmp/user-a/messages/inbox/alice_a1b2/message_1.json":       {`{"participants":[{"name":"Alice"},{"name":"Me"}],"messages":[{"sender_name":"Alice","timestamp_ms":1578201080000,"content":"hi","photos":[{"uri":"messages/inbox/alice_a1b2/photos/1.jpg","creation_timestamp":1578201080}],"reactions":[{"reaction":"ð\u009f\u0098\u008d","actor":"Me"}],"type":"Generic"}],"title":"Alice","is_still_participant":true,"thread_type":"Regular","thread_path":"inbox/alice_a1b2"}`, true},
+		"/tmp/user-a/messages/inbox/alice_a1b2/message_2.json":       {`{"participants":[{"name":"Alice"}],"messages":[{"sender_name":"Alice","content":"hi","type":"Generic"}]}`, false},
+		"/tmp/user-a/messages/inbox/alice_a1b2/photos/1.jpg":         {`DOESN'T MATTER`, false},
+		"/tmp/user-a/messages/inbox/bob_c3d4/message_1.json":         {`{"participants":[],"messages":[]}`, true},
+		"/tmp/user-a/messages/inbox/bob_c3d4/files/message_1.json.x": {`DOESN'T MATTER`, false},
+	}
+	fs := afero.NewMemMapFs()
+	for filename, item := range cases {
+		afero.WriteFile(fs, filename, []byte(item.content), 0644)
+	}
+
+	p := MessagesPattern
+	filenames, err := p.SelectFiles(fs, "/tmp/user-a/messages/inbox")
+	assert.Equal(t, []string{
+		"/tmp/user-a/messages/inbox/alice_a1b2/message_1.json",
+		"/tmp/user-a/messages/inbox/alice_a1b2/message_2.json",
+		"/tmp/user-a/messages/inbox/bob_c3d4/message_1.json",
+	}, filenames)
+	assert.NoError(t, err)
+
+	for _, n := range filenames {
+		data, err := afero.ReadFile(fs, n)
+		assert.NoError(t, err)
+
+		err = p.Validate(data)
+		assert.Equal(t, cases[n].valid, err == nil)
+	}
+}
+
+func TestAdsPatterns(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	afero.WriteFile(fs, "/tmp/user-a/ads_and_businesses/advertisers_who_uploaded_a_contact_list_with_your_information.json", []byte(`{"custom_audiences":["Shop A"]}`), 0644)
+	afero.WriteFile(fs, "/tmp/user-a/ads_and_businesses/advertisers_you've_interacted_with.json", []byte(`{"history":[{"title":"Shoes","action":"Clicked ad","timestamp":1578201080}]}`), 0644)
+	afero.WriteFile(fs, "/tmp/user-a/ads_and_businesses/ads_interests.json", []byte(`{"topics":["Travel"]}`), 0644)
+	afero.WriteFile(fs, "/tmp/user-a/ads_and_businesses/your_off-facebook_activity.json", []byte(`{"off_facebook_activity":[{"name":"shop.example","events":[{"id":123,"type":"PURCHASE"}]}]}`), 0644)
+
+	cases := map[string]struct {
+		pattern Pattern
+		valid   bool
+	}{
+		"/tmp/user-a/ads_and_businesses/advertisers_who_uploaded_a_contact_list_with_your_information.json": {AdvertiserContactListsPattern, true},
+		"/tmp/user-a/ads_and_businesses/advertisers_you've_interacted_with.json":                            {AdInteractionsPattern, true},
+		"/tmp/user-a/ads_and_businesses/ads_interests.json":                                                 {AdInterestsPattern, true},
+		// the timestamp of an event is required
+		"/tmp/user-a/ads_and_businesses/your_off-facebook_activity.json": {OffFacebookActivityPattern, false},
+	}
+	for filename, c := range cases {
+		filenames, err := c.pattern.SelectFiles(fs, "/tmp/user-a/ads_and_businesses")
+		assert.NoError(t, err)
+		assert.Equal(t, []string{filename}, filenames)
+
+		data, err := afero.ReadFile(fs, filename)
+		assert.NoError(t, err)
+		assert.Equal(t, c.valid, c.pattern.Validate(data) == nil, filename)
+	}
+}
+
+func TestSecurityPatterns(t *testing.T) {
+	cases := map[string]testCase{
+		"/tmp/user-a/security_and_login_information/where_you're_logged_in.json": {`{"active_sessions":[{"created_timestamp":1578201080,"ip_address":"1.2.3.4","device":"iPhone"}]}`, true},
+		"/tmp/user-a/security_and_login_information/administrative_records.json": {`{"admin_records":[{"event":"Name Change","session":{"created_timestamp":1578201080},"extra_info":{"old_name":"A"}}]}`, true},
+	}
+	fs := afero.NewMemMapFs()
+	for filename, item := range cases {
+		afero.WriteFile(fs, filename, []byte(item.content), 0644)
+	}
+
+	for _, p := range []Pattern{ActiveSessionsPattern, AdministrativeRecordsPattern} {
+		filenames, err := p.SelectFiles(fs, "/tmp/user-a/security_and_login_information")
+		assert.NoError(t, err)
+		assert.Len(t, filenames, 1)
+
+		for _, n := range filenames {
+			data, err := afero.ReadFile(fs, n)
+			assert.NoError(t, err)
+			assert.Equal(t, cases[n].valid, p.Validate(data) == nil)
+		}
+	}
+
+	// a record without its session is invalid
+	assert.Error(t, AdministrativeRecordsPattern.Validate([]byte(`{"admin_records":[{"event":"Name Change"}]}`)))
+}
+
+func TestMediaPattern(t *testing.T) {
+	cases := map[string]testCase{
+		"/tmp/user-a/photos_and_videos/album/0.json":      {`{"name":"Trip","photos":[{"uri":"photos_and_videos/album/a.jpg","media_metadata":{"photo_metadata":{"camera_make":"Apple","upload_ip":"1.2.3.4"}},"comments":[{"comment":"wow","timestamp":1578201090}]}]}`, true},
+		"/tmp/user-a/photos_and_videos/album/1.json":      {`{"name":"Trip","photos":[{"uri":"photos_and_videos/album/b.jpg","media_metadata":{"photo_metadata":{"camera_make":"Apple"}}}]}`, false},
+		"/tmp/user-a/photos_and_videos/your_videos.json":  {`{"videos":[{"uri":"photos_and_videos/videos/v.mp4","media_metadata":{"video_metadata":{"upload_ip":"1.2.3.4","upload_timestamp":1578201080}}}]}`, true},
+		"/tmp/user-a/photos_and_videos/album/a.jpg":       {`DOESN'T MATTER`, false},
+		"/tmp/user-a/photos_and_videos/videos/v.mp4.json": {`DOESN'T MATTER`, false},
+	}
+	fs := afero.NewMemMapFs()
+	for filename, item := range cases {
+		afero.WriteFile(fs, filename, []byte(item.content), 0644)
+	}
+
+	p := MediaPattern
+	filenames, err := p.SelectFiles(fs, "/tmp/user-a/photos_and_videos")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{
+		"/tmp/user-a/photos_and_videos/album/0.json",
+		"/tmp/user-a/photos_and_videos/album/1.json",
+		"/tmp/user-a/photos_and_videos/your_videos.json",
+	}, filenames)
+
+	for _, n := range filenames {
+		data, err := afero.ReadFile(fs, n)
+		assert.NoError(t, err)
+		assert.Equal(t, cases[n].valid, p.Validate(data) == nil, n)
+	}
+}
+
+func TestTimelinePostsPattern(t *testing.T) {
+	cases := map[string]testCase{
+		"/tmp/user-a/posts/your_posts_1.json":                          {`DOESN'T MATTER`, false},
+		"/tmp/user-a/posts/other_people's_posts_to_your_timeline.json": {`{"wall_posts_sent_to_you":{"activity_log_data":[{"timestamp":1578201080,"title":"Alice wrote on your timeline.","data":[{"post":"Happy birthday"}]}]}}`, true},
+	}
+	fs := afero.NewMemMapFs()
+	for filename, item := range cases {
+		afero.WriteFile(fs, filename, []byte(item.content), 0644)
+	}
+
+	p := TimelinePostsPattern
+	filenames, err := p.SelectFiles(fs, "/tmp/user-a/posts")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"/tmp/user-a/posts/other_people's_posts_to_your_timeline.json"}, filenames)
+
+	data, err := afero.ReadFile(fs, filenames[0])
+	assert.NoError(t, err)
+	assert.NoError(t, p.Validate(data))
+
+	rawTimelinePosts := RawTimelinePosts{}
+	assert.NoError(t, json.Unmarshal(data, &rawTimelinePosts))
+	posts := rawTimelinePosts.ORM(&sequence{}, "owner", "archive")
+	assert.Len(t, posts, 1)
+	assert.Equal(t, "Alice", posts[0].(TimelinePostORM).Author)
+	assert.Equal(t, "owner", posts[0].(TimelinePostORM).DataOwnerID)
+}
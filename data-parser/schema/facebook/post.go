@@ -5,13 +5,12 @@ import (
 	"path/filepath"
 	"time"
 
-	"github.com/alecthomas/jsonschema"
 	"github.com/xeipuuv/gojsonschema"
 )
 
 type Post struct {
 	PKID                  int `gorm:"column:pk_id" sql:"PRIMARY_KEY;DEFAULT:nextval('posts_post_pk_id_seq')"`
-	PostID                int
+	PostID                int64
 	Timestamp             int
 	UpdateTimestamp       int
 	Date                  string
@@ -27,6 +26,7 @@ type Post struct {
 	MediaAttached         bool
 	Sentiment             string
 	DataOwnerID           string
+	ArchiveID             string
 	MediaItems            []PostMedia `gorm:"foreignkey:PostID;association_foreignkey:PKID"`
 	Places                []Place     `gorm:"foreignkey:PostID;association_foreignkey:PKID"`
 	Tags                  []Tag       `gorm:"foreignkey:PostID;association_foreignkey:PKID"`
@@ -37,10 +37,11 @@ func (Post) TableName() string {
 }
 
 type PostMedia struct {
-	PMID              int
+	PMID              int64
 	MediaURI          string
 	FilenameExtension string
 	DataOwnerID       string
+	ArchiveID         string
 	PostID            int `gorm:"column:post_id_id"`
 }
 
@@ -48,14 +49,20 @@ func (PostMedia) TableName() string {
 	return "post_media_postmedia"
 }
 
+// Place is an entry of the places timeline, which comes from a check-in post or the location files.
+// Only places of check-ins have posts.
 type Place struct {
-	PPID        int
+	PPID        int64
 	Name        string
 	Address     string
 	Latitude    float64
 	Longitude   float64
+	Timestamp   int
+	Date        string
+	Source      string
 	DataOwnerID string
-	PostID      int `gorm:"column:post_id_id"`
+	ArchiveID   string
+	PostID      *int `gorm:"column:post_id_id"`
 }
 
 func (Place) TableName() string {
@@ -63,8 +70,9 @@ func (Place) TableName() string {
 }
 
 type Tag struct {
-	TFID        int `gorm:"column:tfid"`
+	TFID        int64 `gorm:"column:tfid"`
 	DataOwnerID string
+	ArchiveID   string
 	PostID      int    `gorm:"column:post_id_id"`
 	FriendID    int    `gorm:"column:tags_id"`
 	Name        string `gorm:"-"`
@@ -78,21 +86,21 @@ type RawPosts struct {
 	Items []*RawPost
 }
 
-func (r *RawPosts) ORM(dataOwner, archiveID string, postID *int, postMediaID *int, placeID *int, tagID *int) ([]interface{}, []Post) {
+func (r *RawPosts) ORM(ids IDGenerator, dataOwner, archiveID string) ([]interface{}, []Post) {
 	posts := make([]interface{}, 0)
 	complexPosts := make([]Post, 0)
 
 	for _, rp := range r.Items {
 		ts := time.Unix(int64(rp.Timestamp), 0)
 		post := Post{
-			PostID:      *postID,
+			PostID:      ids.NextID(),
 			Timestamp:   rp.Timestamp,
-			Date:        dateOfTime(ts),
-			Weekday:     weekdayOfTime(ts),
+			Date:        DateOfTime(ts),
+			Weekday:     WeekdayOfTime(ts),
 			Title:       string(rp.Title),
 			DataOwnerID: dataOwner,
+			ArchiveID:   archiveID,
 		}
-		*postID++
 
 		for _, d := range rp.Data {
 			if d.Post != "" {
@@ -111,12 +119,12 @@ func (r *RawPosts) ORM(dataOwner, archiveID string, postID *int, postMediaID *in
 					post.MediaAttached = true
 					uri := fmt.Sprintf("%s/fb_archives/%s/%s", dataOwner, archiveID, string(item.Media.URI))
 					postMedia := PostMedia{
-						PMID:              *postMediaID,
+						PMID:              ids.NextID(),
 						MediaURI:          uri,
 						FilenameExtension: filepath.Ext(string(item.Media.URI)),
 						DataOwnerID:       dataOwner,
+						ArchiveID:         archiveID,
 					}
-					*postMediaID++
 					post.MediaItems = append(post.MediaItems, postMedia)
 					complex = true
 				}
@@ -132,16 +140,19 @@ func (r *RawPosts) ORM(dataOwner, archiveID string, postID *int, postMediaID *in
 				}
 				if item.Place != nil {
 					place := Place{
-						PPID:        *placeID,
+						PPID:        ids.NextID(),
 						Name:        string(item.Place.Name),
 						Address:     string(item.Place.Address),
+						Timestamp:   rp.Timestamp,
+						Date:        post.Date,
+						Source:      PlaceSourceCheckIn,
 						DataOwnerID: dataOwner,
+						ArchiveID:   archiveID,
 					}
 					if item.Place.Coordinate != nil {
 						place.Latitude = item.Place.Coordinate.Latitude
 						place.Longitude = item.Place.Coordinate.Longitude
 					}
-					*placeID++
 					post.Places = append(post.Places, place)
 					complex = true
 				}
@@ -151,11 +162,11 @@ func (r *RawPosts) ORM(dataOwner, archiveID string, postID *int, postMediaID *in
 		if len(rp.Tags) > 0 {
 			for _, t := range rp.Tags {
 				tag := Tag{
-					TFID:        *tagID,
+					TFID:        ids.NextID(),
 					DataOwnerID: dataOwner,
+					ArchiveID:   archiveID,
 					Name:        string(t),
 				}
-				*tagID++
 				post.Tags = append(post.Tags, tag)
 				complex = true
 			}
@@ -186,20 +197,5 @@ type PostData struct {
 }
 
 func PostArraySchemaLoader() *gojsonschema.Schema {
-	reflector := jsonschema.Reflector{
-		AllowAdditionalProperties:  false,
-		ExpandedStruct:             true,
-		RequiredFromJSONSchemaTags: true,
-	}
-	postSchema := reflector.Reflect(&RawPost{})
-	postsSchema := &jsonschema.Schema{Type: &jsonschema.Type{
-		Version: jsonschema.Version,
-		Type:    "array",
-		Items:   postSchema.Type,
-	}, Definitions: postSchema.Definitions}
-
-	data, _ := postsSchema.MarshalJSON()
-	schemaLoader := gojsonschema.NewStringLoader(string(data))
-	schema, _ := gojsonschema.NewSchema(schemaLoader)
-	return schema
+	return ArraySchemaLoaderOf(&RawPost{})
 }
@@ -0,0 +1,489 @@
+package facebook
+
+import (
+	"strings"
+
+	"github.com/xeipuuv/gojsonschema"
+)
+
+type RawProfile struct {
+	Profile Profile `json:"profile" jsonschema:"required"`
+}
+
+type Profile struct {
+	Name                  *ProfileName            `json:"name" jsonschema:"required"`
+	Emails                *ProfileEmails          `json:"emails"`
+	Birthday              *ProfileDate            `json:"birthday"`
+	Gender                *ProfileGender          `json:"gender"`
+	PreviousNames         []*ProfileOtherName     `json:"previous_names"`
+	OtherNames            []*ProfileOtherName     `json:"other_names"`
+	CurrentCity           *ProfilePlace           `json:"current_city"`
+	Hometown              *ProfilePlace           `json:"hometown"`
+	Relationship          *ProfileRelationship    `json:"relationship"`
+	FamilyMembers         []*FamilyMember         `json:"family_members"`
+	EducationExperiences  []*EducationExperience  `json:"education_experiences"`
+	WorkExperiences       []*WorkExperience       `json:"work_experiences"`
+	Languages             []*ProfilePlace         `json:"languages"`
+	InterestedIn          []MojibakeString        `json:"interested_in"`
+	ReligiousView         *ProfilePlace           `json:"religious_view"`
+	PoliticalView         *ProfilePlace           `json:"political_view"`
+	BloodInfo             *ProfileBloodInfo       `json:"blood_info"`
+	Websites              []*ProfileWebsite       `json:"websites"`
+	PhoneNumbers          []*ProfilePhoneNumber   `json:"phone_numbers"`
+	RegistrationTimestamp int                     `json:"registration_timestamp"`
+	ProfileURI            MojibakeString          `json:"profile_uri"`
+	Username              MojibakeString          `json:"username"`
+	AboutMe               MojibakeString          `json:"about_me"`
+	FavoriteQuotes        MojibakeString          `json:"favorite_quotes"`
+	Intro                 *ProfileIntro           `json:"intro_bio"`
+	Nicknames             []*ProfileNicknameEntry `json:"nicknames"`
+
+	// Not parsed, but allowed. The pages and groups are parsed from their own files.
+	PlacesLived           interface{} `json:"places_lived"`
+	PreviousRelationships interface{} `json:"previous_relationships"`
+	Pages                 interface{} `json:"pages"`
+	Groups                interface{} `json:"groups"`
+	OtherPersonalInfo     interface{} `json:"other_personal_info"`
+}
+
+type ProfileName struct {
+	FullName   MojibakeString `json:"full_name" jsonschema:"required"`
+	FirstName  MojibakeString `json:"first_name"`
+	MiddleName MojibakeString `json:"middle_name"`
+	LastName   MojibakeString `json:"last_name"`
+}
+
+type ProfileEmails struct {
+	Emails          []MojibakeString `json:"emails"`
+	PreviousEmails  []MojibakeString `json:"previous_emails"`
+	PendingEmails   []MojibakeString `json:"pending_emails"`
+	AdAccountEmails []MojibakeString `json:"ad_account_emails"`
+}
+
+type ProfileDate struct {
+	Year  int `json:"year"`
+	Month int `json:"month"`
+	Day   int `json:"day"`
+}
+
+type ProfileGender struct {
+	GenderOption MojibakeString `json:"gender_option"`
+	Pronoun      MojibakeString `json:"pronoun"`
+	CustomGender MojibakeString `json:"custom_gender"`
+}
+
+type ProfileOtherName struct {
+	Name      MojibakeString `json:"name" jsonschema:"required"`
+	Type      MojibakeString `json:"type"`
+	Timestamp int            `json:"timestamp"`
+}
+
+// ProfilePlace is a named value with the time it is set, used by cities, languages and views
+type ProfilePlace struct {
+	Name        MojibakeString `json:"name" jsonschema:"required"`
+	Description MojibakeString `json:"description"`
+	Timestamp   int            `json:"timestamp"`
+}
+
+type ProfileRelationship struct {
+	Status      MojibakeString `json:"status" jsonschema:"required"`
+	Partner     MojibakeString `json:"partner"`
+	Anniversary *ProfileDate   `json:"anniversary"`
+	Timestamp   int            `json:"timestamp"`
+}
+
+type FamilyMember struct {
+	Name      MojibakeString `json:"name" jsonschema:"required"`
+	Relation  MojibakeString `json:"relation" jsonschema:"required"`
+	Timestamp int            `json:"timestamp"`
+}
+
+type EducationExperience struct {
+	Name           MojibakeString   `json:"name" jsonschema:"required"`
+	StartTimestamp int              `json:"start_timestamp"`
+	EndTimestamp   int              `json:"end_timestamp"`
+	Graduated      bool             `json:"graduated"`
+	Description    MojibakeString   `json:"description"`
+	Concentrations []MojibakeString `json:"concentrations"`
+	Degree         MojibakeString   `json:"degree"`
+	SchoolType     MojibakeString   `json:"school_type"`
+	Timestamp      int              `json:"timestamp"`
+}
+
+type WorkExperience struct {
+	Employer       MojibakeString `json:"employer" jsonschema:"required"`
+	Title          MojibakeString `json:"title"`
+	Location       MojibakeString `json:"location"`
+	Description    MojibakeString `json:"description"`
+	StartTimestamp int            `json:"start_timestamp"`
+	EndTimestamp   int            `json:"end_timestamp"`
+	Timestamp      int            `json:"timestamp"`
+}
+
+type ProfileBloodInfo struct {
+	BloodDonorStatus MojibakeString `json:"blood_donor_status"`
+}
+
+type ProfileWebsite struct {
+	Address MojibakeString `json:"address" jsonschema:"required"`
+}
+
+type ProfilePhoneNumber struct {
+	PhoneType   MojibakeString `json:"phone_type"`
+	PhoneNumber MojibakeString `json:"phone_number" jsonschema:"required"`
+	Verified    bool           `json:"verified"`
+}
+
+type ProfileIntro struct {
+	Name      MojibakeString `json:"name"`
+	Timestamp int            `json:"timestamp"`
+}
+
+type ProfileNicknameEntry struct {
+	Nickname  MojibakeString `json:"nickname"`
+	Timestamp int            `json:"timestamp"`
+}
+
+func ProfileSchemaLoader() *gojsonschema.Schema {
+	return SchemaLoaderOf(&RawProfile{})
+}
+
+type RawFriendPeerGroup struct {
+	FriendPeerGroup MojibakeString `json:"friend_peer_group" jsonschema:"required"`
+}
+
+func FriendPeerGroupSchemaLoader() *gojsonschema.Schema {
+	return SchemaLoaderOf(&RawFriendPeerGroup{})
+}
+
+type ProfileORM struct {
+	ProfileID             int64
+	FullName              string
+	FirstName             string
+	MiddleName            string
+	LastName              string
+	Username              string
+	ProfileURI            string
+	Gender                string
+	Pronoun               string
+	BirthYear             int
+	BirthMonth            int
+	BirthDay              int
+	CurrentCity           string
+	Hometown              string
+	RelationshipStatus    string
+	RelationshipPartner   string
+	RelationshipTimestamp int
+	InterestedIn          string
+	ReligiousView         string
+	PoliticalView         string
+	BloodDonorStatus      string
+	AboutMe               string
+	FavoriteQuotes        string
+	RegistrationTimestamp int
+	DataOwnerID           string
+	ArchiveID             string
+}
+
+func (ProfileORM) TableName() string {
+	return "profiles_profile"
+}
+
+const (
+	ProfileEmailCurrent   = "current"
+	ProfileEmailPrevious  = "previous"
+	ProfileEmailPending   = "pending"
+	ProfileEmailAdAccount = "ad_account"
+)
+
+type ProfileEmailORM struct {
+	ProfileID   int64
+	Email       string
+	Type        string
+	DataOwnerID string
+	ArchiveID   string
+}
+
+func (ProfileEmailORM) TableName() string {
+	return "profiles_email"
+}
+
+// ProfileHistoryORM keeps the values a data owner had for an attribute of the profile,
+// like previous names, cities, languages and nicknames.
+type ProfileHistoryORM struct {
+	ProfileID   int64
+	Attribute   string
+	Value       string
+	Type        string
+	Timestamp   int
+	DataOwnerID string
+	ArchiveID   string
+}
+
+func (ProfileHistoryORM) TableName() string {
+	return "profiles_history"
+}
+
+type FamilyMemberORM struct {
+	ProfileID   int64
+	Name        string
+	Relation    string
+	Timestamp   int
+	DataOwnerID string
+	ArchiveID   string
+}
+
+func (FamilyMemberORM) TableName() string {
+	return "profiles_familymember"
+}
+
+type EducationORM struct {
+	ProfileID      int64
+	Name           string
+	SchoolType     string
+	Degree         string
+	Concentrations string
+	Description    string
+	Graduated      bool
+	StartTimestamp int
+	EndTimestamp   int
+	Timestamp      int
+	DataOwnerID    string
+	ArchiveID      string
+}
+
+func (EducationORM) TableName() string {
+	return "profiles_education"
+}
+
+type WorkExperienceORM struct {
+	ProfileID      int64
+	Employer       string
+	Title          string
+	Location       string
+	Description    string
+	StartTimestamp int
+	EndTimestamp   int
+	Timestamp      int
+	DataOwnerID    string
+	ArchiveID      string
+}
+
+func (WorkExperienceORM) TableName() string {
+	return "profiles_workexperience"
+}
+
+type ProfileContactORM struct {
+	ProfileID   int64
+	Type        string // website or the phone type
+	Value       string
+	Verified    bool
+	DataOwnerID string
+	ArchiveID   string
+}
+
+func (ProfileContactORM) TableName() string {
+	return "profiles_contact"
+}
+
+type FriendPeerGroupORM struct {
+	PeerGroup   string
+	DataOwnerID string
+	ArchiveID   string
+}
+
+func (FriendPeerGroupORM) TableName() string {
+	return "profiles_friendpeergroup"
+}
+
+func joinMojibakeStrings(items []MojibakeString, sep string) string {
+	values := make([]string, 0, len(items))
+	for _, item := range items {
+		values = append(values, string(item))
+	}
+	return strings.Join(values, sep)
+}
+
+// ORM returns the profile followed by its history lists.
+func (r RawProfile) ORM(ids IDGenerator, owner, archiveID string) []interface{} {
+	p := r.Profile
+	profile := ProfileORM{
+		ProfileID:             ids.NextID(),
+		Username:              string(p.Username),
+		ProfileURI:            string(p.ProfileURI),
+		InterestedIn:          joinMojibakeStrings(p.InterestedIn, ","),
+		AboutMe:               string(p.AboutMe),
+		FavoriteQuotes:        string(p.FavoriteQuotes),
+		RegistrationTimestamp: p.RegistrationTimestamp,
+		DataOwnerID:           owner,
+		ArchiveID:             archiveID,
+	}
+	if p.Name != nil {
+		profile.FullName = string(p.Name.FullName)
+		profile.FirstName = string(p.Name.FirstName)
+		profile.MiddleName = string(p.Name.MiddleName)
+		profile.LastName = string(p.Name.LastName)
+	}
+	if p.Gender != nil {
+		profile.Gender = string(p.Gender.GenderOption)
+		if p.Gender.CustomGender != "" {
+			profile.Gender = string(p.Gender.CustomGender)
+		}
+		profile.Pronoun = string(p.Gender.Pronoun)
+	}
+	if p.Birthday != nil {
+		profile.BirthYear = p.Birthday.Year
+		profile.BirthMonth = p.Birthday.Month
+		profile.BirthDay = p.Birthday.Day
+	}
+	if p.CurrentCity != nil {
+		profile.CurrentCity = string(p.CurrentCity.Name)
+	}
+	if p.Hometown != nil {
+		profile.Hometown = string(p.Hometown.Name)
+	}
+	if p.Relationship != nil {
+		profile.RelationshipStatus = string(p.Relationship.Status)
+		profile.RelationshipPartner = string(p.Relationship.Partner)
+		profile.RelationshipTimestamp = p.Relationship.Timestamp
+	}
+	if p.ReligiousView != nil {
+		profile.ReligiousView = string(p.ReligiousView.Name)
+	}
+	if p.PoliticalView != nil {
+		profile.PoliticalView = string(p.PoliticalView.Name)
+	}
+	if p.BloodInfo != nil {
+		profile.BloodDonorStatus = string(p.BloodInfo.BloodDonorStatus)
+	}
+
+	result := []interface{}{profile}
+
+	if p.Emails != nil {
+		emails := map[string][]MojibakeString{
+			ProfileEmailCurrent:   p.Emails.Emails,
+			ProfileEmailPrevious:  p.Emails.PreviousEmails,
+			ProfileEmailPending:   p.Emails.PendingEmails,
+			ProfileEmailAdAccount: p.Emails.AdAccountEmails,
+		}
+		for _, emailType := range []string{ProfileEmailCurrent, ProfileEmailPrevious, ProfileEmailPending, ProfileEmailAdAccount} {
+			for _, e := range emails[emailType] {
+				result = append(result, ProfileEmailORM{
+					ProfileID:   profile.ProfileID,
+					Email:       string(e),
+					Type:        emailType,
+					DataOwnerID: owner,
+					ArchiveID:   archiveID,
+				})
+			}
+		}
+	}
+
+	history := func(attribute, value, valueType string, timestamp int) {
+		result = append(result, ProfileHistoryORM{
+			ProfileID:   profile.ProfileID,
+			Attribute:   attribute,
+			Value:       value,
+			Type:        valueType,
+			Timestamp:   timestamp,
+			DataOwnerID: owner,
+			ArchiveID:   archiveID,
+		})
+	}
+	for _, n := range p.PreviousNames {
+		history("previous_name", string(n.Name), string(n.Type), n.Timestamp)
+	}
+	for _, n := range p.OtherNames {
+		history("other_name", string(n.Name), string(n.Type), n.Timestamp)
+	}
+	for _, n := range p.Nicknames {
+		history("nickname", string(n.Nickname), "", n.Timestamp)
+	}
+	for _, l := range p.Languages {
+		history("language", string(l.Name), "", l.Timestamp)
+	}
+	if p.CurrentCity != nil {
+		history("current_city", string(p.CurrentCity.Name), "", p.CurrentCity.Timestamp)
+	}
+	if p.Hometown != nil {
+		history("hometown", string(p.Hometown.Name), "", p.Hometown.Timestamp)
+	}
+	if p.Intro != nil {
+		history("intro", string(p.Intro.Name), "", p.Intro.Timestamp)
+	}
+
+	for _, m := range p.FamilyMembers {
+		result = append(result, FamilyMemberORM{
+			ProfileID:   profile.ProfileID,
+			Name:        string(m.Name),
+			Relation:    string(m.Relation),
+			Timestamp:   m.Timestamp,
+			DataOwnerID: owner,
+			ArchiveID:   archiveID,
+		})
+	}
+
+	for _, e := range p.EducationExperiences {
+		result = append(result, EducationORM{
+			ProfileID:      profile.ProfileID,
+			Name:           string(e.Name),
+			SchoolType:     string(e.SchoolType),
+			Degree:         string(e.Degree),
+			Concentrations: joinMojibakeStrings(e.Concentrations, ","),
+			Description:    string(e.Description),
+			Graduated:      e.Graduated,
+			StartTimestamp: e.StartTimestamp,
+			EndTimestamp:   e.EndTimestamp,
+			Timestamp:      e.Timestamp,
+			DataOwnerID:    owner,
+			ArchiveID:      archiveID,
+		})
+	}
+
+	for _, w := range p.WorkExperiences {
+		result = append(result, WorkExperienceORM{
+			ProfileID:      profile.ProfileID,
+			Employer:       string(w.Employer),
+			Title:          string(w.Title),
+			Location:       string(w.Location),
+			Description:    string(w.Description),
+			StartTimestamp: w.StartTimestamp,
+			EndTimestamp:   w.EndTimestamp,
+			Timestamp:      w.Timestamp,
+			DataOwnerID:    owner,
+			ArchiveID:      archiveID,
+		})
+	}
+
+	for _, w := range p.Websites {
+		result = append(result, ProfileContactORM{
+			ProfileID:   profile.ProfileID,
+			Type:        "website",
+			Value:       string(w.Address),
+			DataOwnerID: owner,
+			ArchiveID:   archiveID,
+		})
+	}
+	for _, n := range p.PhoneNumbers {
+		result = append(result, ProfileContactORM{
+			ProfileID:   profile.ProfileID,
+			Type:        string(n.PhoneType),
+			Value:       string(n.PhoneNumber),
+			Verified:    n.Verified,
+			DataOwnerID: owner,
+			ArchiveID:   archiveID,
+		})
+	}
+
+	return result
+}
+
+func (r RawFriendPeerGroup) ORM(owner, archiveID string) []interface{} {
+	return []interface{}{
+		FriendPeerGroupORM{
+			PeerGroup:   string(r.FriendPeerGroup),
+			DataOwnerID: owner,
+			ArchiveID:   archiveID,
+		},
+	}
+}
@@ -0,0 +1,157 @@
+package facebook
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProfilePatterns(t *testing.T) {
+	cases := map[string]testCase{
+		"/tmp/user-a/profile_information/profile_information.json": {`{"profile":{"name":{"full_name":"JosÃ© Doe","first_name":"JosÃ©","last_name":"Doe"},"emails":{"emails":["jose@example.com"],"previous_emails":["old@example.com"]},"gender":{"gender_option":"MALE","pronoun":"MALE"},"family_members":[{"name":"Ana","relation":"Sister"}]}}`, true},
+		"/tmp/user-a/about_you/friend_peer_group.json":             {`{"friend_peer_group":"Starting Adult Life"}`, true},
+	}
+	fs := afero.NewMemMapFs()
+	for filename, item := range cases {
+		afero.WriteFile(fs, filename, []byte(item.content), 0644)
+	}
+	afero.WriteFile(fs, "/tmp/user-a/profile_information/profile_update_history.json", []byte(`DOESN'T MATTER`), 0644)
+
+	for _, c := range []struct {
+		pattern Pattern
+		dir     string
+	}{
+		{ProfilePattern, "/tmp/user-a/profile_information"},
+		{FriendPeerGroupPattern, "/tmp/user-a/about_you"},
+	} {
+		filenames, err := c.pattern.SelectFiles(fs, c.dir)
+		assert.NoError(t, err)
+		assert.Len(t, filenames, 1)
+
+		for _, n := range filenames {
+			data, err := afero.ReadFile(fs, n)
+			assert.NoError(t, err)
+			assert.Equal(t, cases[n].valid, c.pattern.Validate(data) == nil, n)
+		}
+	}
+
+	// the sections which aren't parsed are allowed
+	assert.NoError(t, ProfilePattern.Validate([]byte(`{"profile":{
+		"name":{"full_name":"Jose"},
+		"places_lived":[{"place":"Taipei, Taiwan","start_timestamp":1278201080}],
+		"previous_relationships":[{"name":"Ana","timestamp":1278201090,"anniversary":{"year":2010,"month":1,"day":2}}],
+		"pages":[{"name":"Favorite Athletes","pages":["Alice"]}],
+		"groups":[{"name":"Runners","timestamp":1278201100}],
+		"other_personal_info":{"name":"Jose","value":"left-handed"}
+	}}`)))
+
+	// the full name is required
+	assert.Error(t, ProfilePattern.Validate([]byte(`{"profile":{"name":{"first_name":"Jose"}}}`)))
+	// so is the relation of a family member
+	assert.Error(t, ProfilePattern.Validate([]byte(`{"profile":{"name":{"full_name":"Jose"},"family_members":[{"name":"Ana"}]}}`)))
+	assert.Error(t, FriendPeerGroupPattern.Validate([]byte(`{}`)))
+}
+
+func TestProfileORM(t *testing.T) {
+	data := []byte(`{"profile":{
+		"name":{"full_name":"JosÃ© Doe","first_name":"JosÃ©","last_name":"Doe"},
+		"emails":{"emails":["jose@example.com"],"previous_emails":["old@example.com"],"ad_account_emails":["ads@example.com"]},
+		"birthday":{"year":1990,"month":1,"day":2},
+		"gender":{"gender_option":"CUSTOM","custom_gender":"Nonbinary","pronoun":"NEUTRAL"},
+		"previous_names":[{"name":"Joe","timestamp":1578201080}],
+		"current_city":{"name":"Taipei","timestamp":1578201090},
+		"relationship":{"status":"Married","partner":"Ana","timestamp":1578201100},
+		"family_members":[{"name":"Bea","relation":"Sister","timestamp":1578201110}],
+		"education_experiences":[{"name":"NTU","graduated":true,"concentrations":["Math","Physics"],"school_type":"College"}],
+		"work_experiences":[{"employer":"Bitmark","title":"Engineer","start_timestamp":1578201120}],
+		"languages":[{"name":"English"}],
+		"interested_in":["Women","Men"],
+		"websites":[{"address":"https://example.com"}],
+		"phone_numbers":[{"phone_type":"Mobile","phone_number":"+886 900","verified":true}],
+		"registration_timestamp":1278201080
+	}}`)
+	assert.NoError(t, ProfilePattern.Validate(data))
+
+	var raw RawProfile
+	assert.NoError(t, json.Unmarshal(data, &raw))
+	rows := raw.ORM(&sequence{}, "owner", "archive")
+
+	profile := rows[0].(ProfileORM)
+	assert.Equal(t, int64(1), profile.ProfileID)
+	assert.Equal(t, "José Doe", profile.FullName)
+	assert.Equal(t, "Nonbinary", profile.Gender)
+	assert.Equal(t, "NEUTRAL", profile.Pronoun)
+	assert.Equal(t, 1990, profile.BirthYear)
+	assert.Equal(t, "Taipei", profile.CurrentCity)
+	assert.Equal(t, "Married", profile.RelationshipStatus)
+	assert.Equal(t, "Ana", profile.RelationshipPartner)
+	assert.Equal(t, "Women,Men", profile.InterestedIn)
+	assert.Equal(t, 1278201080, profile.RegistrationTimestamp)
+	assert.Equal(t, "owner", profile.DataOwnerID)
+
+	emails := make([]ProfileEmailORM, 0)
+	history := make([]ProfileHistoryORM, 0)
+	family := make([]FamilyMemberORM, 0)
+	education := make([]EducationORM, 0)
+	work := make([]WorkExperienceORM, 0)
+	contacts := make([]ProfileContactORM, 0)
+	for _, row := range rows[1:] {
+		switch r := row.(type) {
+		case ProfileEmailORM:
+			emails = append(emails, r)
+		case ProfileHistoryORM:
+			history = append(history, r)
+		case FamilyMemberORM:
+			family = append(family, r)
+		case EducationORM:
+			education = append(education, r)
+		case WorkExperienceORM:
+			work = append(work, r)
+		case ProfileContactORM:
+			contacts = append(contacts, r)
+		default:
+			t.Fatalf("unexpected row: %#v", row)
+		}
+	}
+
+	assert.Equal(t, []ProfileEmailORM{
+		{ProfileID: 1, Email: "jose@example.com", Type: ProfileEmailCurrent, DataOwnerID: "owner", ArchiveID: "archive"},
+		{ProfileID: 1, Email: "old@example.com", Type: ProfileEmailPrevious, DataOwnerID: "owner", ArchiveID: "archive"},
+		{ProfileID: 1, Email: "ads@example.com", Type: ProfileEmailAdAccount, DataOwnerID: "owner", ArchiveID: "archive"},
+	}, emails)
+
+	assert.Len(t, history, 3)
+	assert.Equal(t, "previous_name", history[0].Attribute)
+	assert.Equal(t, "Joe", history[0].Value)
+	assert.Equal(t, 1578201080, history[0].Timestamp)
+	assert.Equal(t, "language", history[1].Attribute)
+	assert.Equal(t, "current_city", history[2].Attribute)
+	assert.Equal(t, 1578201090, history[2].Timestamp)
+
+	assert.Equal(t, []FamilyMemberORM{
+		{ProfileID: 1, Name: "Bea", Relation: "Sister", Timestamp: 1578201110, DataOwnerID: "owner", ArchiveID: "archive"},
+	}, family)
+
+	assert.Len(t, education, 1)
+	assert.Equal(t, "Math,Physics", education[0].Concentrations)
+	assert.True(t, education[0].Graduated)
+
+	assert.Len(t, work, 1)
+	assert.Equal(t, "Bitmark", work[0].Employer)
+	assert.Equal(t, 1578201120, work[0].StartTimestamp)
+
+	assert.Equal(t, []ProfileContactORM{
+		{ProfileID: 1, Type: "website", Value: "https://example.com", DataOwnerID: "owner", ArchiveID: "archive"},
+		{ProfileID: 1, Type: "Mobile", Value: "+886 900", Verified: true, DataOwnerID: "owner", ArchiveID: "archive"},
+	}, contacts)
+}
+
+func TestFriendPeerGroupORM(t *testing.T) {
+	var raw RawFriendPeerGroup
+	assert.NoError(t, json.Unmarshal([]byte(`{"friend_peer_group":"Starting Adult Life"}`), &raw))
+	assert.Equal(t, []interface{}{
+		FriendPeerGroupORM{PeerGroup: "Starting Adult Life", DataOwnerID: "owner", ArchiveID: "archive"},
+	}, raw.ORM("owner", "archive"))
+}
@@ -0,0 +1,200 @@
+package facebook
+
+import (
+	"bytes"
+	"encoding/json"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/xeipuuv/gojsonschema"
+)
+
+// MaxQuarantinedSize is the number of bytes kept of the JSON and the messages of a quarantined record.
+const MaxQuarantinedSize = 64 * 1024
+
+// QuarantineORM is a record left out of parsing since it doesn't match the schema of its pattern.
+type QuarantineORM struct {
+	Pattern string
+	File    string
+	// Pointer is the JSON pointer of the record in the file, it is empty if the whole file is left out
+	Pointer  string
+	Messages string
+	// Record is the JSON of the record, which is not kept if the whole file is left out.
+	// It is cut at MaxQuarantinedSize bytes, and so are the messages.
+	Record      string
+	DataOwnerID string
+	ArchiveID   string
+}
+
+func (QuarantineORM) TableName() string {
+	return "parser_quarantinedrecord"
+}
+
+func (ctx *ParseContext) quarantine(p *Pattern, file, pointer string, record []byte, reasons []string) error {
+	ctx.Quarantined = append(ctx.Quarantined, QuarantineORM{
+		Pattern:     p.Name,
+		File:        file,
+		Pointer:     pointer,
+		Messages:    truncate(strings.Join(reasons, "\n"), MaxQuarantinedSize),
+		Record:      truncate(string(record), MaxQuarantinedSize),
+		DataOwnerID: ctx.DataOwner,
+		ArchiveID:   ctx.ArchiveID,
+	})
+	ctx.QuarantinedCount++
+
+	if ctx.QuarantineSink != nil && len(ctx.Quarantined) >= ctx.batchSize() {
+		return ctx.FlushQuarantine()
+	}
+	return nil
+}
+
+// FlushQuarantine persists the quarantined records through QuarantineSink.
+func (ctx *ParseContext) FlushQuarantine() error {
+	if ctx.QuarantineSink == nil || len(ctx.Quarantined) == 0 {
+		return nil
+	}
+	if err := ctx.QuarantineSink.BulkInsert(ctx.Quarantined); err != nil {
+		return err
+	}
+	ctx.Quarantined = nil
+	return nil
+}
+
+// truncate cuts s at n bytes without splitting a UTF-8 character.
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n]
+}
+
+// ValidateFile validates the file against the schema of the pattern. An invalid file is an error in strict mode.
+// Otherwise the invalid records are quarantined and the file is returned without them, or nil if the file
+// is invalid as a whole, for example a required key is missing.
+func (ctx *ParseContext) ValidateFile(p *Pattern, file string, data []byte) ([]byte, error) {
+	if ctx.Strict {
+		if err := p.Validate(data); err != nil {
+			return nil, err
+		}
+		return data, nil
+	}
+
+	result, err := p.Schema.Validate(gojsonschema.NewBytesLoader(data))
+	if err != nil {
+		return nil, ctx.quarantine(p, file, "", nil, []string{err.Error()})
+	}
+	if result.Valid() {
+		return data, nil
+	}
+
+	var doc interface{}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	if err := dec.Decode(&doc); err != nil {
+		return nil, err
+	}
+
+	// the errors are grouped by the records they are found in
+	records := make(map[string]*invalidRecord)
+	pointers := make([]string, 0)
+	for _, e := range result.Errors() {
+		path, ok := recordPathOf(doc, contextSegments(e.Context()))
+		if !ok {
+			return nil, ctx.quarantine(p, file, "", nil, reasonsOf(result.Errors()))
+		}
+
+		pointer := jsonPointer(path)
+		r, ok := records[pointer]
+		if !ok {
+			r = &invalidRecord{path: path}
+			records[pointer] = r
+			pointers = append(pointers, pointer)
+		}
+		r.reasons = append(r.reasons, e.String())
+	}
+
+	// records are removed from the last one, so that the indexes of the others stay the same
+	sort.SliceStable(pointers, func(i, j int) bool {
+		return records[pointers[i]].index() > records[pointers[j]].index()
+	})
+	for _, pointer := range pointers {
+		r := records[pointer]
+		var record []byte
+		doc, record = withoutRecord(doc, r.path)
+		if err := ctx.quarantine(p, file, pointer, record, r.reasons); err != nil {
+			return nil, err
+		}
+	}
+
+	valid, err := json.Marshal(doc)
+	if err != nil {
+		return nil, err
+	}
+	return valid, nil
+}
+
+type invalidRecord struct {
+	path    []string
+	reasons []string
+}
+
+func (r *invalidRecord) index() int {
+	i, _ := strconv.Atoi(r.path[len(r.path)-1])
+	return i
+}
+
+// contextSegments splits the context of a validation error into keys and indexes, without the root.
+func contextSegments(c *gojsonschema.JsonContext) []string {
+	const delimiter = "\x00"
+	segments := strings.Split(c.String(delimiter), delimiter)
+	return segments[1:]
+}
+
+// recordPathOf returns the path of the record where the error of the path is found.
+// Records are the items of the first array along the path, an error outside of them is not in any record.
+func recordPathOf(doc interface{}, path []string) ([]string, bool) {
+	v := doc
+	for i, segment := range path {
+		switch c := v.(type) {
+		case []interface{}:
+			return path[:i+1], true
+		case map[string]interface{}:
+			v = c[segment]
+		default:
+			return nil, false
+		}
+	}
+	return nil, false
+}
+
+// withoutRecord removes the record of the path from the doc, and returns it as JSON.
+// The path is made of the keys of objects followed by the index of the record.
+func withoutRecord(doc interface{}, path []string) (interface{}, []byte) {
+	if len(path) == 1 {
+		items := doc.([]interface{})
+		i, _ := strconv.Atoi(path[0])
+		record, _ := json.Marshal(items[i])
+		return append(items[:i:i], items[i+1:]...), record
+	}
+
+	object := doc.(map[string]interface{})
+	var record []byte
+	object[path[0]], record = withoutRecord(object[path[0]], path[1:])
+	return object, record
+}
+
+// jsonPointer returns the JSON pointer of the path as defined by RFC 6901.
+func jsonPointer(path []string) string {
+	escaper := strings.NewReplacer("~", "~0", "/", "~1")
+	var b strings.Builder
+	for _, segment := range path {
+		b.WriteString("/")
+		b.WriteString(escaper.Replace(segment))
+	}
+	return b.String()
+}
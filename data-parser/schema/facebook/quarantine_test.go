@@ -0,0 +1,102 @@
+package facebook
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateFileLeniently(t *testing.T) {
+	ctx := NewParseContext(&sequence{}, "owner", "archive", &memorySink{}, nil, "")
+
+	data, err := ctx.ValidateFile(&FriendsPattern, "friends/friends.json", []byte(`{"friends": [
+		{"name": "Alice", "timestamp": 1578201080},
+		{"name": "Bob", "timestamp": 1578201090, "phone": "123"},
+		{"name": "Carol", "timestamp": 1578201100},
+		{"timestamp": 1578201110}
+	]}`))
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"friends": [
+		{"name": "Alice", "timestamp": 1578201080},
+		{"name": "Carol", "timestamp": 1578201100}
+	]}`, string(data))
+
+	if assert.Len(t, ctx.Quarantined, 2) {
+		bob := ctx.Quarantined[1].(QuarantineORM)
+		assert.Equal(t, "friends", bob.Pattern)
+		assert.Equal(t, "/friends/1", bob.Pointer)
+		assert.Contains(t, bob.Messages, "phone")
+		assert.JSONEq(t, `{"name": "Bob", "timestamp": 1578201090, "phone": "123"}`, bob.Record)
+		assert.Equal(t, "/friends/3", ctx.Quarantined[0].(QuarantineORM).Pointer)
+	}
+
+	// a file whose top-level object is invalid is left out as a whole
+	ctx.Quarantined = nil
+	data, err = ctx.ValidateFile(&FriendsPattern, "friends/friends.json", []byte(`{"friends": [], "unknown": 1}`))
+	assert.NoError(t, err)
+	assert.Nil(t, data)
+	if assert.Len(t, ctx.Quarantined, 1) {
+		assert.Equal(t, "", ctx.Quarantined[0].(QuarantineORM).Pointer)
+	}
+
+	ctx.Strict = true
+	_, err = ctx.ValidateFile(&FriendsPattern, "friends/friends.json", []byte(`{"friends": [{"timestamp": 1578201110}]}`))
+	assert.Error(t, err)
+}
+
+func TestStreamLeniently(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	afero.WriteFile(fs, "posts/your_posts_1.json", []byte(`[
+		{"timestamp": 1578201080, "data": [{"post": "hello"}]},
+		{"timestamp": 1578201090, "unknown": true},
+		{"timestamp": 1578201100, "data": [{"post": "world"}]}
+	]`), 0644)
+
+	sink := &memorySink{}
+	ctx := NewParseContext(&sequence{}, "owner", "archive", sink, nil, "")
+	assert.NoError(t, streamerOf(t, "posts").Stream(ctx, fs, "posts/your_posts_1.json"))
+	assert.Len(t, sink.rows, 2)
+	if assert.Len(t, ctx.Quarantined, 1) {
+		assert.Equal(t, "/1", ctx.Quarantined[0].(QuarantineORM).Pointer)
+	}
+}
+
+func TestQuarantineInBatches(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	afero.WriteFile(fs, "comments/comments.json", []byte(`{"comments": [
+		{"timestamp": 1578201080, "title": "Me commented on a post.", "author": "Me"},
+		{"timestamp": 1578201090, "title": "Me commented on a post.", "author": "Me"},
+		{"timestamp": 1578201100, "title": "Me commented on a post.", "author": "Me"},
+		{"timestamp": 1578201110, "title": "Me commented on a post.", "author": "Me"},
+		{"timestamp": 1578201120, "title": "Me commented on a post.", "author": "Me"}
+	]}`), 0644)
+
+	quarantineSink := &memorySink{}
+	ctx := NewParseContext(&sequence{}, "owner", "archive", &memorySink{}, nil, "")
+	ctx.BatchSize = 2
+	ctx.QuarantineSink = quarantineSink
+	assert.NoError(t, streamerOf(t, "comments").Stream(ctx, fs, "comments/comments.json"))
+	// no more than a batch is kept in memory
+	assert.Len(t, ctx.Quarantined, 1)
+	assert.Len(t, quarantineSink.rows, 4)
+
+	assert.NoError(t, ctx.FlushQuarantine())
+	assert.Len(t, ctx.Quarantined, 0)
+	assert.Len(t, quarantineSink.rows, 5)
+	assert.Equal(t, 3, quarantineSink.batches)
+	assert.Equal(t, 5, ctx.QuarantinedCount)
+}
+
+func TestQuarantineLargeRecord(t *testing.T) {
+	ctx := NewParseContext(&sequence{}, "owner", "archive", &memorySink{}, nil, "")
+	record := []byte(`{"title": "` + strings.Repeat("é", MaxQuarantinedSize) + `"}`)
+	assert.NoError(t, ctx.quarantine(&CommentsPattern, "comments/comments.json", "/comments/0", record, []string{"invalid"}))
+
+	quarantined := ctx.Quarantined[0].(QuarantineORM).Record
+	assert.True(t, len(quarantined) <= MaxQuarantinedSize)
+	assert.True(t, utf8.ValidString(quarantined))
+	assert.True(t, strings.HasPrefix(string(record), quarantined))
+}
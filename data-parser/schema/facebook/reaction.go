@@ -49,24 +49,25 @@ type ReactionORM struct {
 	Actor       string
 	Reaction    string
 	DataOwnerID string
+	ArchiveID   string
 }
 
 func (ReactionORM) TableName() string {
 	return "reactions_reaction"
 }
 
-func (r RawReactions) ORM(parseTime int64, owner string) []interface{} {
-	idx := 0
+func (r RawReactions) ORM(ids IDGenerator, owner, archiveID string) []interface{} {
 	result := make([]interface{}, 0)
 	for _, r := range r.Reactions {
 		t := time.Unix(int64(r.Timestamp), 0)
 		orm := ReactionORM{
-			ReactionID:  tableForeignKey(parseTime, idx),
+			ReactionID:  ids.NextID(),
 			Timestamp:   r.Timestamp,
-			Date:        dateOfTime(t),
-			Weekday:     weekdayOfTime(t),
+			Date:        DateOfTime(t),
+			Weekday:     WeekdayOfTime(t),
 			Title:       string(r.Title),
 			DataOwnerID: owner,
+			ArchiveID:   archiveID,
 		}
 		if len(r.Data) > 0 {
 			orm.Actor = string(r.Data[0].Reaction.Actor)
@@ -74,7 +75,6 @@ func (r RawReactions) ORM(parseTime int64, owner string) []interface{} {
 		}
 
 		result = append(result, orm)
-		idx++
 	}
 	return result
 }
@@ -0,0 +1,411 @@
+package facebook
+
+import (
+	"path/filepath"
+)
+
+// Handlers of a Facebook archive, registered in the order that they run.
+var Handlers = &Registry{}
+
+func init() {
+	Handlers.Register(&Category{
+		Files:  ProfilePattern,
+		Tables: []interface{}{ProfileORM{}, ProfileEmailORM{}, ProfileHistoryORM{}, FamilyMemberORM{}, EducationORM{}, WorkExperienceORM{}, ProfileContactORM{}},
+		NewRaw: func() interface{} { return &RawProfile{} },
+		TransformFunc: func(ctx *ParseContext, file string, v interface{}) ([]interface{}, error) {
+			return v.(*RawProfile).ORM(ctx.IDs, ctx.DataOwner, ctx.ArchiveID), nil
+		},
+	})
+	Handlers.Register(&Category{
+		Files:  FriendPeerGroupPattern,
+		Tables: []interface{}{FriendPeerGroupORM{}},
+		NewRaw: func() interface{} { return &RawFriendPeerGroup{} },
+		TransformFunc: func(ctx *ParseContext, file string, v interface{}) ([]interface{}, error) {
+			return v.(*RawFriendPeerGroup).ORM(ctx.DataOwner, ctx.ArchiveID), nil
+		},
+	})
+	Handlers.Register(&Category{
+		Files:  FriendsPattern,
+		Tables: []interface{}{FriendORM{}},
+		NewRaw: func() interface{} { return &RawFriends{} },
+		TransformFunc: func(ctx *ParseContext, file string, v interface{}) ([]interface{}, error) {
+			return v.(*RawFriends).ORM(ctx.IDs, ctx.DataOwner, ctx.ArchiveID), nil
+		},
+	})
+	Handlers.Register(&Category{
+		Files:  FriendshipEventsPattern,
+		Tables: []interface{}{FriendshipEventORM{}},
+		NewRaw: func() interface{} { return &RawFriendshipEvents{} },
+		Needs:  []string{FriendsPattern.Name},
+		TransformFunc: func(ctx *ParseContext, file string, v interface{}) ([]interface{}, error) {
+			friendIDs, err := ctx.Sink.FriendIDs(ctx.DataOwner, ctx.ArchiveID)
+			if err != nil {
+				return nil, err
+			}
+			return v.(*RawFriendshipEvents).ORM(ctx.IDs, ctx.DataOwner, ctx.ArchiveID, friendIDs), nil
+		},
+	})
+	Handlers.Register(&StreamCategory{
+		Category: Category{
+			Files:         PostsPattern,
+			Tables:        []interface{}{Post{}, PostMedia{}, Place{}, Tag{}},
+			NewRaw:        func() interface{} { return &[]*RawPost{} },
+			TransformFunc: transformPosts,
+			PersistFunc:   persistPosts,
+			// tags refer to friends
+			Needs: []string{FriendsPattern.Name},
+		},
+		Items: Items{
+			NewItem: func() interface{} { return &RawPost{} },
+			Schema:  SchemaLoaderOf(&RawPost{}),
+			TransformFunc: func(ctx *ParseContext, file string, header, item interface{}) ([]interface{}, error) {
+				return transformPosts(ctx, file, &[]*RawPost{item.(*RawPost)})
+			},
+		},
+	})
+	Handlers.Register(&StreamCategory{
+		Category: Category{
+			Files:  ReactionsPattern,
+			Tables: []interface{}{ReactionORM{}},
+			NewRaw: func() interface{} { return &RawReactions{} },
+			TransformFunc: func(ctx *ParseContext, file string, v interface{}) ([]interface{}, error) {
+				return v.(*RawReactions).ORM(ctx.IDs, ctx.DataOwner, ctx.ArchiveID), nil
+			},
+		},
+		Items: Items{
+			Key:     "reactions",
+			NewItem: func() interface{} { return &Reaction{} },
+			Schema:  SchemaLoaderOf(&Reaction{}),
+			TransformFunc: func(ctx *ParseContext, file string, header, item interface{}) ([]interface{}, error) {
+				return RawReactions{Reactions: []*Reaction{item.(*Reaction)}}.ORM(ctx.IDs, ctx.DataOwner, ctx.ArchiveID), nil
+			},
+		},
+	})
+	Handlers.Register(&Category{
+		Files:  GroupMembershipPattern,
+		Tables: []interface{}{GroupORM{}},
+		NewRaw: func() interface{} { return &RawGroupMembership{} },
+		TransformFunc: func(ctx *ParseContext, file string, v interface{}) ([]interface{}, error) {
+			return v.(*RawGroupMembership).ORM(ctx.IDs, ctx.DataOwner, ctx.ArchiveID, ctx.GroupIDs), nil
+		},
+	})
+	Handlers.Register(&Category{
+		Files:  GroupActivitiesPattern,
+		Tables: []interface{}{GroupORM{}, GroupActivityORM{}},
+		NewRaw: func() interface{} { return &RawGroupActivities{} },
+		Needs:  []string{GroupMembershipPattern.Name},
+		TransformFunc: func(ctx *ParseContext, file string, v interface{}) ([]interface{}, error) {
+			return v.(*RawGroupActivities).ORM(ctx.IDs, ctx.DataOwner, ctx.ArchiveID, ctx.GroupIDs), nil
+		},
+	})
+	Handlers.Register(&StreamCategory{
+		Category: Category{
+			Files:  CommentsPattern,
+			Tables: []interface{}{GroupORM{}, CommentORM{}},
+			NewRaw: func() interface{} { return &RawComments{} },
+			Needs:  []string{GroupMembershipPattern.Name},
+			TransformFunc: func(ctx *ParseContext, file string, v interface{}) ([]interface{}, error) {
+				return v.(*RawComments).ORM(ctx.IDs, ctx.DataOwner, ctx.ArchiveID, ctx.GroupIDs), nil
+			},
+			// a batch of items may have the comments in a group before the group
+			PersistFunc: func(ctx *ParseContext, rows []interface{}) error {
+				return BulkInsertInOrder(ctx.Sink, []interface{}{GroupORM{}, CommentORM{}}, rows)
+			},
+		},
+		Items: Items{
+			Key:     "comments",
+			NewItem: func() interface{} { return &Comment{} },
+			Schema:  SchemaLoaderOf(&Comment{}),
+			TransformFunc: func(ctx *ParseContext, file string, header, item interface{}) ([]interface{}, error) {
+				return RawComments{Comments: []Comment{*item.(*Comment)}}.ORM(ctx.IDs, ctx.DataOwner, ctx.ArchiveID, ctx.GroupIDs), nil
+			},
+		},
+	})
+	Handlers.Register(&Category{
+		Files:  LocationHistoryPattern,
+		Tables: []interface{}{Place{}},
+		NewRaw: func() interface{} { return &RawLocationHistory{} },
+		TransformFunc: func(ctx *ParseContext, file string, v interface{}) ([]interface{}, error) {
+			return ctx.Places.Dedup(v.(*RawLocationHistory).ORM(ctx.IDs, ctx.DataOwner, ctx.ArchiveID)), nil
+		},
+	})
+	Handlers.Register(&Category{
+		Files:  PrimaryLocationPattern,
+		Tables: []interface{}{Place{}},
+		NewRaw: func() interface{} { return &RawPrimaryLocation{} },
+		TransformFunc: func(ctx *ParseContext, file string, v interface{}) ([]interface{}, error) {
+			return ctx.Places.Dedup(v.(*RawPrimaryLocation).ORM(ctx.IDs, ctx.DataOwner, ctx.ArchiveID)), nil
+		},
+	})
+	Handlers.Register(&Category{
+		Files:  LoginsAndLogoutsPattern,
+		Tables: []interface{}{LoginEventORM{}},
+		NewRaw: func() interface{} { return &RawLoginsAndLogouts{} },
+		TransformFunc: func(ctx *ParseContext, file string, v interface{}) ([]interface{}, error) {
+			return v.(*RawLoginsAndLogouts).ORM(ctx.IDs, ctx.DataOwner, ctx.ArchiveID), nil
+		},
+	})
+	Handlers.Register(&Category{
+		Files:  AccountActivityPattern,
+		Tables: []interface{}{LoginEventORM{}},
+		NewRaw: func() interface{} { return &RawAccountActivity{} },
+		TransformFunc: func(ctx *ParseContext, file string, v interface{}) ([]interface{}, error) {
+			return v.(*RawAccountActivity).ORM(ctx.IDs, ctx.DataOwner, ctx.ArchiveID), nil
+		},
+	})
+	Handlers.Register(&Category{
+		Files:  UsedIPAddressesPattern,
+		Tables: []interface{}{IPAddressORM{}},
+		NewRaw: func() interface{} { return &RawUsedIPAddresses{} },
+		TransformFunc: func(ctx *ParseContext, file string, v interface{}) ([]interface{}, error) {
+			return v.(*RawUsedIPAddresses).ORM(ctx.DataOwner, ctx.ArchiveID), nil
+		},
+	})
+	Handlers.Register(&Category{
+		Files:  ActiveSessionsPattern,
+		Tables: []interface{}{SessionORM{}},
+		NewRaw: func() interface{} { return &RawActiveSessions{} },
+		TransformFunc: func(ctx *ParseContext, file string, v interface{}) ([]interface{}, error) {
+			return v.(*RawActiveSessions).ORM(ctx.IDs, ctx.DataOwner, ctx.ArchiveID), nil
+		},
+	})
+	Handlers.Register(&Category{
+		Files:  AdministrativeRecordsPattern,
+		Tables: []interface{}{LoginEventORM{}},
+		NewRaw: func() interface{} { return &RawAdministrativeRecords{} },
+		TransformFunc: func(ctx *ParseContext, file string, v interface{}) ([]interface{}, error) {
+			return v.(*RawAdministrativeRecords).ORM(ctx.IDs, ctx.DataOwner, ctx.ArchiveID), nil
+		},
+	})
+	Handlers.Register(&Category{
+		Files:  PageLikesPattern,
+		Tables: []interface{}{PageLikeORM{}},
+		NewRaw: func() interface{} { return &RawPageLikes{} },
+		TransformFunc: func(ctx *ParseContext, file string, v interface{}) ([]interface{}, error) {
+			return v.(*RawPageLikes).ORM(ctx.DataOwner, ctx.ArchiveID), nil
+		},
+	})
+	Handlers.Register(&Category{
+		Files:  EventResponsesPattern,
+		Tables: []interface{}{EventResponseORM{}},
+		NewRaw: func() interface{} { return &RawEventResponses{} },
+		TransformFunc: func(ctx *ParseContext, file string, v interface{}) ([]interface{}, error) {
+			return v.(*RawEventResponses).ORM(ctx.IDs, ctx.DataOwner, ctx.ArchiveID), nil
+		},
+	})
+	Handlers.Register(&Category{
+		Files:  EventInvitationsPattern,
+		Tables: []interface{}{EventResponseORM{}},
+		NewRaw: func() interface{} { return &RawEventInvitations{} },
+		TransformFunc: func(ctx *ParseContext, file string, v interface{}) ([]interface{}, error) {
+			return v.(*RawEventInvitations).ORM(ctx.IDs, ctx.DataOwner, ctx.ArchiveID), nil
+		},
+	})
+	Handlers.Register(&Category{
+		Files:  FollowersPattern,
+		Tables: []interface{}{FollowORM{}},
+		NewRaw: func() interface{} { return &RawFollowers{} },
+		TransformFunc: func(ctx *ParseContext, file string, v interface{}) ([]interface{}, error) {
+			return v.(*RawFollowers).ORM(ctx.DataOwner, ctx.ArchiveID), nil
+		},
+	})
+	Handlers.Register(&Category{
+		Files:  FollowingPattern,
+		Tables: []interface{}{FollowORM{}},
+		NewRaw: func() interface{} { return &RawFollowing{} },
+		TransformFunc: func(ctx *ParseContext, file string, v interface{}) ([]interface{}, error) {
+			return v.(*RawFollowing).ORM(ctx.DataOwner, ctx.ArchiveID), nil
+		},
+	})
+	Handlers.Register(&Category{
+		Files:  FollowedPagesPattern,
+		Tables: []interface{}{FollowORM{}},
+		NewRaw: func() interface{} { return &RawFollowedPages{} },
+		TransformFunc: func(ctx *ParseContext, file string, v interface{}) ([]interface{}, error) {
+			return v.(*RawFollowedPages).ORM(ctx.DataOwner, ctx.ArchiveID), nil
+		},
+	})
+	Handlers.Register(&Category{
+		Files:  TimelinePostsPattern,
+		Tables: []interface{}{TimelinePostORM{}},
+		NewRaw: func() interface{} { return &RawTimelinePosts{} },
+		TransformFunc: func(ctx *ParseContext, file string, v interface{}) ([]interface{}, error) {
+			return v.(*RawTimelinePosts).ORM(ctx.IDs, ctx.DataOwner, ctx.ArchiveID), nil
+		},
+	})
+	Handlers.Register(&Category{
+		Files:  SearchHistoryPattern,
+		Tables: []interface{}{SearchORM{}},
+		NewRaw: func() interface{} { return &RawSearchHistory{} },
+		TransformFunc: func(ctx *ParseContext, file string, v interface{}) ([]interface{}, error) {
+			return v.(*RawSearchHistory).ORM(ctx.IDs, ctx.DataOwner, ctx.ArchiveID), nil
+		},
+	})
+	Handlers.Register(&Category{
+		Files:  SavedItemsPattern,
+		Tables: []interface{}{SavedItemORM{}},
+		NewRaw: func() interface{} { return &RawSavedItems{} },
+		TransformFunc: func(ctx *ParseContext, file string, v interface{}) ([]interface{}, error) {
+			return v.(*RawSavedItems).ORM(ctx.IDs, ctx.DataOwner, ctx.ArchiveID), nil
+		},
+	})
+	Handlers.Register(&Category{
+		Files:  StoriesPattern,
+		Tables: []interface{}{StoryORM{}},
+		NewRaw: func() interface{} { return &RawStories{} },
+		TransformFunc: func(ctx *ParseContext, file string, v interface{}) ([]interface{}, error) {
+			return v.(*RawStories).ORM(ctx.IDs, ctx.DataOwner, ctx.ArchiveID), nil
+		},
+		Media: true,
+	})
+	Handlers.Register(&StreamCategory{
+		Category: Category{
+			Files:         MessagesPattern,
+			Tables:        []interface{}{ConversationORM{}, ParticipantORM{}, MessageORM{}, MessageAttachmentORM{}, MessageReactionORM{}},
+			NewRaw:        func() interface{} { return &RawConversation{} },
+			TransformFunc: transformConversation,
+			// photos, videos and files sent in conversations, the parsed conversations are not kept
+			Media: true,
+		},
+		Items: Items{
+			Key:     "messages",
+			NewItem: func() interface{} { return &Message{} },
+			Schema:  SchemaLoaderOf(&Message{}),
+			HeaderFunc: func(ctx *ParseContext, file string, header interface{}) ([]interface{}, error) {
+				_, rows := conversationOf(ctx, file, header.(*RawConversation))
+				return rows, nil
+			},
+			TransformFunc: func(ctx *ParseContext, file string, header, item interface{}) ([]interface{}, error) {
+				conversationID, _ := conversationOf(ctx, file, header.(*RawConversation))
+				rawConversation := RawConversation{Messages: []*Message{item.(*Message)}}
+				return rawConversation.MessagesORM(ctx.IDs, conversationID, ctx.DataOwner, ctx.ArchiveID), nil
+			},
+		},
+	})
+	Handlers.Register(&Category{
+		Files:  AdvertiserContactListsPattern,
+		Tables: []interface{}{AdvertiserContactListORM{}},
+		NewRaw: func() interface{} { return &RawAdvertiserContactLists{} },
+		TransformFunc: func(ctx *ParseContext, file string, v interface{}) ([]interface{}, error) {
+			return v.(*RawAdvertiserContactLists).ORM(ctx.DataOwner, ctx.ArchiveID), nil
+		},
+	})
+	Handlers.Register(&Category{
+		Files:  AdInteractionsPattern,
+		Tables: []interface{}{AdInteractionORM{}},
+		NewRaw: func() interface{} { return &RawAdInteractions{} },
+		TransformFunc: func(ctx *ParseContext, file string, v interface{}) ([]interface{}, error) {
+			return v.(*RawAdInteractions).ORM(ctx.IDs, ctx.DataOwner, ctx.ArchiveID), nil
+		},
+	})
+	Handlers.Register(&Category{
+		Files:  AdInterestsPattern,
+		Tables: []interface{}{AdInterestORM{}},
+		NewRaw: func() interface{} { return &RawAdInterests{} },
+		TransformFunc: func(ctx *ParseContext, file string, v interface{}) ([]interface{}, error) {
+			return v.(*RawAdInterests).ORM(ctx.DataOwner, ctx.ArchiveID), nil
+		},
+	})
+	Handlers.Register(&Category{
+		Files:  OffFacebookActivityPattern,
+		Tables: []interface{}{OffFacebookActivityORM{}},
+		NewRaw: func() interface{} { return &RawOffFacebookActivities{} },
+		TransformFunc: func(ctx *ParseContext, file string, v interface{}) ([]interface{}, error) {
+			return v.(*RawOffFacebookActivities).ORM(ctx.IDs, ctx.DataOwner, ctx.ArchiveID), nil
+		},
+	})
+	Handlers.Register(&Category{
+		Files:  MediaPattern,
+		Tables: []interface{}{AlbumORM{}, MediaItemORM{}, MediaMetadataORM{}, MediaCommentORM{}},
+		NewRaw: func() interface{} { return &RawMediaFile{} },
+		TransformFunc: func(ctx *ParseContext, file string, v interface{}) ([]interface{}, error) {
+			return v.(*RawMediaFile).ORM(ctx.IDs, ctx.DataOwner, ctx.ArchiveID), nil
+		},
+		// the albums and videos are parsed into rows, only the photos and videos are uploaded
+		Media: true,
+	})
+	Handlers.Register(&Category{
+		Files: FilesPattern,
+		Media: true,
+	})
+}
+
+// transformPosts returns the posts with their media, places and tags.
+func transformPosts(ctx *ParseContext, file string, v interface{}) ([]interface{}, error) {
+	rawPosts := RawPosts{Items: *v.(*[]*RawPost)}
+	posts, complexPosts := rawPosts.ORM(ctx.IDs, ctx.DataOwner, ctx.ArchiveID)
+	for _, p := range complexPosts {
+		// places of check-ins are kept for their posts even if they are duplicated
+		for _, place := range p.Places {
+			ctx.Places.Add(place)
+		}
+		posts = append(posts, p)
+	}
+	return posts, nil
+}
+
+// persistPosts inserts the posts without media, places and tags in one go,
+// while others are created along with their associations.
+func persistPosts(ctx *ParseContext, rows []interface{}) error {
+	posts := make([]interface{}, 0)
+	for _, row := range rows {
+		p := row.(Post)
+		if len(p.MediaItems) == 0 && len(p.Places) == 0 && len(p.Tags) == 0 {
+			posts = append(posts, p)
+		}
+	}
+	if err := ctx.Sink.BulkInsert(posts); err != nil {
+		return err
+	}
+
+	for _, row := range rows {
+		p := row.(Post)
+		if len(p.MediaItems) == 0 && len(p.Places) == 0 && len(p.Tags) == 0 {
+			continue
+		}
+
+		if len(p.Tags) > 0 {
+			// friends must exist for inserting tags
+			friendIDs, err := ctx.Sink.FriendIDs(ctx.DataOwner, ctx.ArchiveID)
+			if err != nil {
+				return err
+			}
+
+			// FIXME: non-friends couldn't be tagged
+			c := 0 // valid tag count
+			for i := range p.Tags {
+				friendID, ok := friendIDs[p.Tags[i].Name]
+				if ok {
+					p.Tags[i].FriendID = friendID
+					c++
+				}
+			}
+			p.Tags = p.Tags[:c]
+		}
+
+		if err := ctx.Sink.Create(&p); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// transformConversation returns the conversation, if it hasn't been parsed, followed by the messages.
+func transformConversation(ctx *ParseContext, file string, v interface{}) ([]interface{}, error) {
+	rawConversation := v.(*RawConversation)
+	conversationID, result := conversationOf(ctx, file, rawConversation)
+	return append(result, rawConversation.MessagesORM(ctx.IDs, conversationID, ctx.DataOwner, ctx.ArchiveID)...), nil
+}
+
+// conversationOf returns the id of the conversation of the file, along with its rows if it hasn't been parsed.
+// A conversation is split into message_1.json, message_2.json and so on in its dir.
+func conversationOf(ctx *ParseContext, file string, rawConversation *RawConversation) (int64, []interface{}) {
+	threadDir := filepath.Dir(file)
+	if conversationID, ok := ctx.ConversationIDs[threadDir]; ok {
+		return conversationID, nil
+	}
+
+	conversationID := ctx.IDs.NextID()
+	ctx.ConversationIDs[threadDir] = conversationID
+	return conversationID, rawConversation.ConversationORM(conversationID, ctx.DataOwner, ctx.ArchiveID)
+}
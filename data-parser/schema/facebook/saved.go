@@ -0,0 +1,65 @@
+package facebook
+
+import (
+	"time"
+
+	"github.com/xeipuuv/gojsonschema"
+)
+
+type RawSavedItems struct {
+	SavesAndCollections []*SavedItem `json:"saves_and_collections" jsonschema:"required"`
+}
+
+type SavedItem struct {
+	Timestamp   int            `json:"timestamp" jsonschema:"required"`
+	Title       MojibakeString `json:"title"`
+	Attachments []*Attachment  `json:"attachments"`
+}
+
+func SavedItemSchemaLoader() *gojsonschema.Schema {
+	return SchemaLoaderOf(&RawSavedItems{})
+}
+
+type SavedItemORM struct {
+	SavedItemID int64
+	Title       string
+	Name        string
+	Source      string
+	URL         string
+	Timestamp   int
+	Date        string
+	Weekday     int
+	DataOwnerID string
+	ArchiveID   string
+}
+
+func (SavedItemORM) TableName() string {
+	return "saved_items_saveditem"
+}
+
+func (r RawSavedItems) ORM(ids IDGenerator, owner, archiveID string) []interface{} {
+	result := make([]interface{}, 0)
+	for _, s := range r.SavesAndCollections {
+		t := time.Unix(int64(s.Timestamp), 0)
+		orm := SavedItemORM{
+			SavedItemID: ids.NextID(),
+			Title:       string(s.Title),
+			Timestamp:   s.Timestamp,
+			Date:        DateOfTime(t),
+			Weekday:     WeekdayOfTime(t),
+			DataOwnerID: owner,
+			ArchiveID:   archiveID,
+		}
+		for _, a := range s.Attachments {
+			for _, item := range a.Data {
+				if item.ExternalContext != nil {
+					orm.Name = string(item.ExternalContext.Name)
+					orm.Source = string(item.ExternalContext.Source)
+					orm.URL = string(item.ExternalContext.URL)
+				}
+			}
+		}
+		result = append(result, orm)
+	}
+	return result
+}
@@ -0,0 +1,45 @@
+package facebook
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSavedItemsORM(t *testing.T) {
+	data := []byte(`{"saves_and_collections":[
+		{"timestamp":1578201080,"title":"You saved a link.","attachments":[{"data":[{"external_context":{"name":"CafÃ© guide","source":"example.com","url":"https://example.com/guide"}}]}]},
+		{"timestamp":1578201090,"title":"You saved a post."}
+	]}`)
+	assert.NoError(t, SavedItemsPattern.Validate(data))
+
+	var raw RawSavedItems
+	assert.NoError(t, json.Unmarshal(data, &raw))
+	saved := time.Unix(1578201080, 0)
+	savedPost := time.Unix(1578201090, 0)
+	assert.Equal(t, []interface{}{
+		SavedItemORM{
+			SavedItemID: 1,
+			Title:       "You saved a link.",
+			Name:        "Café guide",
+			Source:      "example.com",
+			URL:         "https://example.com/guide",
+			Timestamp:   1578201080,
+			Date:        DateOfTime(saved),
+			Weekday:     WeekdayOfTime(saved),
+			DataOwnerID: "owner",
+			ArchiveID:   "archive",
+		},
+		SavedItemORM{
+			SavedItemID: 2,
+			Title:       "You saved a post.",
+			Timestamp:   1578201090,
+			Date:        DateOfTime(savedPost),
+			Weekday:     WeekdayOfTime(savedPost),
+			DataOwnerID: "owner",
+			ArchiveID:   "archive",
+		},
+	}, raw.ORM(&sequence{}, "owner", "archive"))
+}
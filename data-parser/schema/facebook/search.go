@@ -0,0 +1,70 @@
+package facebook
+
+import (
+	"time"
+
+	"github.com/xeipuuv/gojsonschema"
+)
+
+type RawSearchHistory struct {
+	Searches []*Search `json:"searches" jsonschema:"required"`
+}
+
+type Search struct {
+	Timestamp   int            `json:"timestamp" jsonschema:"required"`
+	Title       MojibakeString `json:"title"`
+	Data        []*SearchData  `json:"data"`
+	Attachments []*Attachment  `json:"attachments"`
+}
+
+type SearchData struct {
+	Text MojibakeString `json:"text" jsonschema:"required"`
+}
+
+func SearchHistorySchemaLoader() *gojsonschema.Schema {
+	return SchemaLoaderOf(&RawSearchHistory{})
+}
+
+type SearchORM struct {
+	SearchID    int64
+	Query       string
+	Title       string
+	Timestamp   int
+	Date        string
+	Weekday     int
+	DataOwnerID string
+	ArchiveID   string
+}
+
+func (SearchORM) TableName() string {
+	return "searches_search"
+}
+
+func (r RawSearchHistory) ORM(ids IDGenerator, owner, archiveID string) []interface{} {
+	result := make([]interface{}, 0)
+	for _, s := range r.Searches {
+		t := time.Unix(int64(s.Timestamp), 0)
+		orm := SearchORM{
+			SearchID:    ids.NextID(),
+			Title:       string(s.Title),
+			Timestamp:   s.Timestamp,
+			Date:        DateOfTime(t),
+			Weekday:     WeekdayOfTime(t),
+			DataOwnerID: owner,
+			ArchiveID:   archiveID,
+		}
+		// the query is kept in both data and attachments
+		if len(s.Data) > 0 {
+			orm.Query = string(s.Data[0].Text)
+		}
+		for _, a := range s.Attachments {
+			for _, item := range a.Data {
+				if orm.Query == "" && item.Text != "" {
+					orm.Query = string(item.Text)
+				}
+			}
+		}
+		result = append(result, orm)
+	}
+	return result
+}
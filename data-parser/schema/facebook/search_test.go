@@ -0,0 +1,45 @@
+package facebook
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSearchHistoryORM(t *testing.T) {
+	data := []byte(`{"searches":[
+		{"timestamp":1578201080,"title":"You searched Facebook","data":[{"text":"CafÃ©"}],"attachments":[{"data":[{"text":"ignored"}]}]},
+		{"timestamp":1578201090,"title":"You visited Alice","attachments":[{"data":[{"name":"Alice"},{"text":"Alice"}]}]}
+	]}`)
+	assert.NoError(t, SearchHistoryPattern.Validate(data))
+
+	var raw RawSearchHistory
+	assert.NoError(t, json.Unmarshal(data, &raw))
+	searched := time.Unix(1578201080, 0)
+	visited := time.Unix(1578201090, 0)
+	// the query is taken from the attachments if the search has no data
+	assert.Equal(t, []interface{}{
+		SearchORM{
+			SearchID:    1,
+			Query:       "Café",
+			Title:       "You searched Facebook",
+			Timestamp:   1578201080,
+			Date:        DateOfTime(searched),
+			Weekday:     WeekdayOfTime(searched),
+			DataOwnerID: "owner",
+			ArchiveID:   "archive",
+		},
+		SearchORM{
+			SearchID:    2,
+			Query:       "Alice",
+			Title:       "You visited Alice",
+			Timestamp:   1578201090,
+			Date:        DateOfTime(visited),
+			Weekday:     WeekdayOfTime(visited),
+			DataOwnerID: "owner",
+			ArchiveID:   "archive",
+		},
+	}, raw.ORM(&sequence{}, "owner", "archive"))
+}
@@ -0,0 +1,237 @@
+package facebook
+
+import (
+	"time"
+
+	"github.com/xeipuuv/gojsonschema"
+)
+
+const (
+	LoginEventSourceLoginsAndLogouts      = "logins_and_logouts"
+	LoginEventSourceAccountActivity       = "account_activity"
+	LoginEventSourceAdministrativeRecords = "administrative_records"
+)
+
+type RawLoginsAndLogouts struct {
+	AccountAccesses []*AccountAccess `json:"account_accesses" jsonschema:"required"`
+}
+
+type AccountAccess struct {
+	Action    MojibakeString `json:"action" jsonschema:"required"`
+	Timestamp int            `json:"timestamp" jsonschema:"required"`
+	Site      MojibakeString `json:"site"`
+	IPAddress string         `json:"ip_address"`
+}
+
+type RawAccountActivity struct {
+	AccountActivity []*AccountActivity `json:"account_activity" jsonschema:"required"`
+}
+
+type AccountActivity struct {
+	Action     MojibakeString `json:"action" jsonschema:"required"`
+	Timestamp  int            `json:"timestamp" jsonschema:"required"`
+	IPAddress  string         `json:"ip_address"`
+	UserAgent  MojibakeString `json:"user_agent"`
+	DatrCookie string         `json:"datr_cookie"`
+	City       MojibakeString `json:"city"`
+	Region     MojibakeString `json:"region"`
+	Country    MojibakeString `json:"country"`
+	SiteName   MojibakeString `json:"site_name"`
+}
+
+type RawUsedIPAddresses struct {
+	UsedIPAddress []*UsedIPAddress `json:"used_ip_address" jsonschema:"required"`
+}
+
+type UsedIPAddress struct {
+	IP        string         `json:"ip" jsonschema:"required"`
+	Action    MojibakeString `json:"action"`
+	Timestamp int            `json:"timestamp" jsonschema:"required"`
+}
+
+type RawActiveSessions struct {
+	ActiveSessions []*Session `json:"active_sessions" jsonschema:"required"`
+}
+
+type Session struct {
+	CreatedTimestamp int            `json:"created_timestamp" jsonschema:"required"`
+	UpdatedTimestamp int            `json:"updated_timestamp"`
+	IPAddress        string         `json:"ip_address"`
+	UserAgent        MojibakeString `json:"user_agent"`
+	DatrCookie       string         `json:"datr_cookie"`
+	Device           MojibakeString `json:"device"`
+	Location         MojibakeString `json:"location"`
+	App              MojibakeString `json:"app"`
+}
+
+type RawAdministrativeRecords struct {
+	AdminRecords []*AdministrativeRecord `json:"admin_records" jsonschema:"required"`
+}
+
+type AdministrativeRecord struct {
+	Event   MojibakeString `json:"event" jsonschema:"required"`
+	Session *Session       `json:"session" jsonschema:"required"`
+	// the content depends on the event, for example the old and new names of a name change
+	ExtraInfo map[string]interface{} `json:"extra_info"`
+}
+
+func LoginsAndLogoutsSchemaLoader() *gojsonschema.Schema {
+	return SchemaLoaderOf(&RawLoginsAndLogouts{})
+}
+
+func AccountActivitySchemaLoader() *gojsonschema.Schema {
+	return SchemaLoaderOf(&RawAccountActivity{})
+}
+
+func UsedIPAddressSchemaLoader() *gojsonschema.Schema {
+	return SchemaLoaderOf(&RawUsedIPAddresses{})
+}
+
+func ActiveSessionSchemaLoader() *gojsonschema.Schema {
+	return SchemaLoaderOf(&RawActiveSessions{})
+}
+
+func AdministrativeRecordSchemaLoader() *gojsonschema.Schema {
+	return SchemaLoaderOf(&RawAdministrativeRecords{})
+}
+
+// LoginEventORM is an account event such as login, logout and password change.
+type LoginEventORM struct {
+	EventID     int64
+	Action      string
+	Timestamp   int
+	Date        string
+	Weekday     int
+	Site        string
+	IPAddress   string
+	UserAgent   string
+	DatrCookie  string
+	City        string
+	Region      string
+	Country     string
+	Source      string
+	DataOwnerID string
+	ArchiveID   string
+}
+
+func (LoginEventORM) TableName() string {
+	return "security_loginevent"
+}
+
+type IPAddressORM struct {
+	IPAddress   string
+	Action      string
+	Timestamp   int
+	DataOwnerID string
+	ArchiveID   string
+}
+
+func (IPAddressORM) TableName() string {
+	return "security_ipaddress"
+}
+
+// SessionORM is a device where the data owner is logged in.
+type SessionORM struct {
+	SessionID        int64
+	CreatedTimestamp int
+	UpdatedTimestamp int
+	IPAddress        string
+	UserAgent        string
+	DatrCookie       string
+	Device           string
+	Location         string
+	App              string
+	DataOwnerID      string
+	ArchiveID        string
+}
+
+func (SessionORM) TableName() string {
+	return "security_session"
+}
+
+func newLoginEvent(ids IDGenerator, action string, timestamp int, source, owner, archiveID string) LoginEventORM {
+	t := time.Unix(int64(timestamp), 0)
+	return LoginEventORM{
+		EventID:     ids.NextID(),
+		Action:      action,
+		Timestamp:   timestamp,
+		Date:        DateOfTime(t),
+		Weekday:     WeekdayOfTime(t),
+		Source:      source,
+		DataOwnerID: owner,
+		ArchiveID:   archiveID,
+	}
+}
+
+func (r RawLoginsAndLogouts) ORM(ids IDGenerator, owner, archiveID string) []interface{} {
+	result := make([]interface{}, 0)
+	for _, a := range r.AccountAccesses {
+		orm := newLoginEvent(ids, string(a.Action), a.Timestamp, LoginEventSourceLoginsAndLogouts, owner, archiveID)
+		orm.Site = string(a.Site)
+		orm.IPAddress = a.IPAddress
+		result = append(result, orm)
+	}
+	return result
+}
+
+func (r RawAccountActivity) ORM(ids IDGenerator, owner, archiveID string) []interface{} {
+	result := make([]interface{}, 0)
+	for _, a := range r.AccountActivity {
+		orm := newLoginEvent(ids, string(a.Action), a.Timestamp, LoginEventSourceAccountActivity, owner, archiveID)
+		orm.Site = string(a.SiteName)
+		orm.IPAddress = a.IPAddress
+		orm.UserAgent = string(a.UserAgent)
+		orm.DatrCookie = a.DatrCookie
+		orm.City = string(a.City)
+		orm.Region = string(a.Region)
+		orm.Country = string(a.Country)
+		result = append(result, orm)
+	}
+	return result
+}
+
+func (r RawAdministrativeRecords) ORM(ids IDGenerator, owner, archiveID string) []interface{} {
+	result := make([]interface{}, 0)
+	for _, a := range r.AdminRecords {
+		orm := newLoginEvent(ids, string(a.Event), a.Session.CreatedTimestamp, LoginEventSourceAdministrativeRecords, owner, archiveID)
+		orm.IPAddress = a.Session.IPAddress
+		orm.UserAgent = string(a.Session.UserAgent)
+		orm.DatrCookie = a.Session.DatrCookie
+		result = append(result, orm)
+	}
+	return result
+}
+
+func (r RawUsedIPAddresses) ORM(owner, archiveID string) []interface{} {
+	result := make([]interface{}, 0)
+	for _, a := range r.UsedIPAddress {
+		result = append(result, IPAddressORM{
+			IPAddress:   a.IP,
+			Action:      string(a.Action),
+			Timestamp:   a.Timestamp,
+			DataOwnerID: owner,
+			ArchiveID:   archiveID,
+		})
+	}
+	return result
+}
+
+func (r RawActiveSessions) ORM(ids IDGenerator, owner, archiveID string) []interface{} {
+	result := make([]interface{}, 0)
+	for _, s := range r.ActiveSessions {
+		result = append(result, SessionORM{
+			SessionID:        ids.NextID(),
+			CreatedTimestamp: s.CreatedTimestamp,
+			UpdatedTimestamp: s.UpdatedTimestamp,
+			IPAddress:        s.IPAddress,
+			UserAgent:        string(s.UserAgent),
+			DatrCookie:       s.DatrCookie,
+			Device:           string(s.Device),
+			Location:         string(s.Location),
+			App:              string(s.App),
+			DataOwnerID:      owner,
+			ArchiveID:        archiveID,
+		})
+	}
+	return result
+}
@@ -0,0 +1,117 @@
+package facebook
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestActiveSessionsORM(t *testing.T) {
+	data := []byte(`{"active_sessions":[{
+		"created_timestamp":1578201080,"updated_timestamp":1578201090,"ip_address":"1.2.3.4",
+		"user_agent":"Mozilla/5.0","datr_cookie":"COOKIE","device":"iPhone","location":"Taipei, Taiwan","app":"Facebook for iOS"
+	}]}`)
+	assert.NoError(t, ActiveSessionsPattern.Validate(data))
+
+	var raw RawActiveSessions
+	assert.NoError(t, json.Unmarshal(data, &raw))
+	assert.Equal(t, []interface{}{
+		SessionORM{
+			SessionID:        1,
+			CreatedTimestamp: 1578201080,
+			UpdatedTimestamp: 1578201090,
+			IPAddress:        "1.2.3.4",
+			UserAgent:        "Mozilla/5.0",
+			DatrCookie:       "COOKIE",
+			Device:           "iPhone",
+			Location:         "Taipei, Taiwan",
+			App:              "Facebook for iOS",
+			DataOwnerID:      "owner",
+			ArchiveID:        "archive",
+		},
+	}, raw.ORM(&sequence{}, "owner", "archive"))
+}
+
+func TestLoginEventsORM(t *testing.T) {
+	loggedIn := time.Unix(1578201080, 0)
+	changed := time.Unix(1578201090, 0)
+
+	var logins RawLoginsAndLogouts
+	assert.NoError(t, json.Unmarshal([]byte(`{"account_accesses":[
+		{"action":"Login","timestamp":1578201080,"site":"www.facebook.com","ip_address":"1.2.3.4"}
+	]}`), &logins))
+	assert.Equal(t, []interface{}{
+		LoginEventORM{
+			EventID:     1,
+			Action:      "Login",
+			Timestamp:   1578201080,
+			Date:        DateOfTime(loggedIn),
+			Weekday:     WeekdayOfTime(loggedIn),
+			Site:        "www.facebook.com",
+			IPAddress:   "1.2.3.4",
+			Source:      LoginEventSourceLoginsAndLogouts,
+			DataOwnerID: "owner",
+			ArchiveID:   "archive",
+		},
+	}, logins.ORM(&sequence{}, "owner", "archive"))
+
+	var activity RawAccountActivity
+	assert.NoError(t, json.Unmarshal([]byte(`{"account_activity":[
+		{"action":"Password Changed","timestamp":1578201090,"ip_address":"5.6.7.8","user_agent":"Mozilla/5.0",
+		 "datr_cookie":"COOKIE","city":"TaipÃ©i","region":"Taipei","country":"TW","site_name":"Facebook"}
+	]}`), &activity))
+	assert.Equal(t, []interface{}{
+		LoginEventORM{
+			EventID:     1,
+			Action:      "Password Changed",
+			Timestamp:   1578201090,
+			Date:        DateOfTime(changed),
+			Weekday:     WeekdayOfTime(changed),
+			Site:        "Facebook",
+			IPAddress:   "5.6.7.8",
+			UserAgent:   "Mozilla/5.0",
+			DatrCookie:  "COOKIE",
+			City:        "Taipéi",
+			Region:      "Taipei",
+			Country:     "TW",
+			Source:      LoginEventSourceAccountActivity,
+			DataOwnerID: "owner",
+			ArchiveID:   "archive",
+		},
+	}, activity.ORM(&sequence{}, "owner", "archive"))
+
+	// an administrative record happens when its session is created
+	var records RawAdministrativeRecords
+	assert.NoError(t, json.Unmarshal([]byte(`{"admin_records":[
+		{"event":"Name Change","session":{"created_timestamp":1578201080,"ip_address":"1.2.3.4","user_agent":"Mozilla/5.0","datr_cookie":"COOKIE"},"extra_info":{"old_name":"A"}}
+	]}`), &records))
+	assert.Equal(t, []interface{}{
+		LoginEventORM{
+			EventID:     1,
+			Action:      "Name Change",
+			Timestamp:   1578201080,
+			Date:        DateOfTime(loggedIn),
+			Weekday:     WeekdayOfTime(loggedIn),
+			IPAddress:   "1.2.3.4",
+			UserAgent:   "Mozilla/5.0",
+			DatrCookie:  "COOKIE",
+			Source:      LoginEventSourceAdministrativeRecords,
+			DataOwnerID: "owner",
+			ArchiveID:   "archive",
+		},
+	}, records.ORM(&sequence{}, "owner", "archive"))
+}
+
+func TestUsedIPAddressesORM(t *testing.T) {
+	data := []byte(`{"used_ip_address":[{"ip":"1.2.3.4","action":"Login","timestamp":1578201080},{"ip":"2001:db8::1","timestamp":1578201090}]}`)
+	assert.NoError(t, UsedIPAddressesPattern.Validate(data))
+
+	var raw RawUsedIPAddresses
+	assert.NoError(t, json.Unmarshal(data, &raw))
+	assert.Equal(t, []interface{}{
+		IPAddressORM{IPAddress: "1.2.3.4", Action: "Login", Timestamp: 1578201080, DataOwnerID: "owner", ArchiveID: "archive"},
+		IPAddressORM{IPAddress: "2001:db8::1", Timestamp: 1578201090, DataOwnerID: "owner", ArchiveID: "archive"},
+	}, raw.ORM("owner", "archive"))
+}
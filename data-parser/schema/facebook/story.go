@@ -0,0 +1,96 @@
+package facebook
+
+import (
+	"path/filepath"
+
+	"github.com/xeipuuv/gojsonschema"
+)
+
+const (
+	StoryTypeArchived = "archived"
+	StoryTypeReaction = "reaction"
+)
+
+// RawStories is either stories/archived_stories.json or stories/story_reactions.json,
+// each of them has only one of the lists.
+type RawStories struct {
+	ArchivedStories []*Story         `json:"archived_stories"`
+	StoriesFeedback []*StoryFeedback `json:"stories_feedback"`
+}
+
+type Story struct {
+	Timestamp   int            `json:"timestamp" jsonschema:"required"`
+	Title       MojibakeString `json:"title"`
+	Attachments []*Attachment  `json:"attachments"`
+}
+
+type StoryFeedback struct {
+	Timestamp int                  `json:"timestamp" jsonschema:"required"`
+	Title     MojibakeString       `json:"title"`
+	Data      []*StoryFeedbackData `json:"data"`
+}
+
+type StoryFeedbackData struct {
+	Reaction MojibakeString `json:"reaction"`
+	Message  MojibakeString `json:"message"`
+}
+
+func StorySchemaLoader() *gojsonschema.Schema {
+	return SchemaLoaderOf(&RawStories{})
+}
+
+type StoryORM struct {
+	StoryID           int64
+	Type              string
+	Title             string
+	Reaction          string
+	MediaURI          string
+	FilenameExtension string
+	Timestamp         int
+	DataOwnerID       string
+	ArchiveID         string
+}
+
+func (StoryORM) TableName() string {
+	return "stories_story"
+}
+
+func (r RawStories) ORM(ids IDGenerator, owner, archiveID string) []interface{} {
+	result := make([]interface{}, 0)
+	for _, s := range r.ArchivedStories {
+		orm := StoryORM{
+			StoryID:     ids.NextID(),
+			Type:        StoryTypeArchived,
+			Title:       string(s.Title),
+			Timestamp:   s.Timestamp,
+			DataOwnerID: owner,
+			ArchiveID:   archiveID,
+		}
+		for _, a := range s.Attachments {
+			for _, item := range a.Data {
+				if item.Media != nil {
+					orm.MediaURI = MediaKey(owner, archiveID, item.Media.URI)
+					orm.FilenameExtension = filepath.Ext(string(item.Media.URI))
+				}
+			}
+		}
+		result = append(result, orm)
+	}
+	for _, f := range r.StoriesFeedback {
+		orm := StoryORM{
+			StoryID:     ids.NextID(),
+			Type:        StoryTypeReaction,
+			Title:       string(f.Title),
+			Timestamp:   f.Timestamp,
+			DataOwnerID: owner,
+			ArchiveID:   archiveID,
+		}
+		for _, d := range f.Data {
+			if d.Reaction != "" {
+				orm.Reaction = string(d.Reaction)
+			}
+		}
+		result = append(result, orm)
+	}
+	return result
+}
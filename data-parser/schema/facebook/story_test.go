@@ -0,0 +1,48 @@
+package facebook
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStoriesORM(t *testing.T) {
+	archived := []byte(`{"archived_stories":[
+		{"timestamp":1578201080,"title":"Trip","attachments":[{"data":[{"media":{"uri":"stories/202001/a.jpg","creation_timestamp":1578201080}}]}]}
+	]}`)
+	reactions := []byte(`{"stories_feedback":[
+		{"timestamp":1578201090,"title":"You reacted to Alice's story.","data":[{"message":"nice"},{"reaction":"ð\u009f\u0098\u008d"}]}
+	]}`)
+
+	var rows []interface{}
+	ids := &sequence{}
+	for _, data := range [][]byte{archived, reactions} {
+		assert.NoError(t, StoriesPattern.Validate(data))
+		var raw RawStories
+		assert.NoError(t, json.Unmarshal(data, &raw))
+		rows = append(rows, raw.ORM(ids, "owner", "archive")...)
+	}
+
+	assert.Equal(t, []interface{}{
+		StoryORM{
+			StoryID:           1,
+			Type:              StoryTypeArchived,
+			Title:             "Trip",
+			MediaURI:          MediaKey("owner", "archive", "stories/202001/a.jpg"),
+			FilenameExtension: ".jpg",
+			Timestamp:         1578201080,
+			DataOwnerID:       "owner",
+			ArchiveID:         "archive",
+		},
+		StoryORM{
+			StoryID:     2,
+			Type:        StoryTypeReaction,
+			Title:       "You reacted to Alice's story.",
+			Reaction:    "😍",
+			Timestamp:   1578201090,
+			DataOwnerID: "owner",
+			ArchiveID:   "archive",
+		},
+	}, rows)
+}
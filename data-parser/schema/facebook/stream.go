@@ -0,0 +1,259 @@
+package facebook
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/afero"
+	"github.com/xeipuuv/gojsonschema"
+)
+
+// DefaultBatchSize is the number of rows persisted at once while streaming a file.
+const DefaultBatchSize = 1000
+
+// Streamer is implemented by the handlers that stream their files rather than decoding them at once,
+// so that the memory used doesn't grow with the size of a file.
+type Streamer interface {
+	// Stream validates, decodes and transforms the items of the file one by one,
+	// and persists the rows in batches.
+	Stream(ctx *ParseContext, fs afero.Fs, file string) error
+}
+
+// Items is the array of a file that is streamed item by item.
+type Items struct {
+	// Key of the array in the top-level object, or empty if the file is a top-level array.
+	Key     string
+	NewItem func() interface{}
+	Schema  *gojsonschema.Schema
+	// HeaderFunc, if set, transforms the header into rows persisted before those of the items.
+	HeaderFunc func(ctx *ParseContext, file string, header interface{}) ([]interface{}, error)
+	// TransformFunc transforms an item into rows. The header is the file decoded by NewRaw without
+	// the items, or nil if the file is a top-level array.
+	TransformFunc func(ctx *ParseContext, file string, header, item interface{}) ([]interface{}, error)
+}
+
+// StreamCategory is a Category whose files are streamed.
+type StreamCategory struct {
+	Category
+	Items Items
+}
+
+func (c *StreamCategory) Stream(ctx *ParseContext, fs afero.Fs, file string) error {
+	var header interface{}
+	var version *SchemaVersion
+	// the key of the items in the file, which differs from Items.Key in other formats
+	itemsKey := c.Items.Key
+	if c.Items.Key != "" {
+		var err error
+		if header, version, itemsKey, err = c.readHeader(ctx, fs, file); err != nil {
+			return err
+		}
+		// the file is quarantined as a whole
+		if header == nil {
+			return nil
+		}
+
+		if c.Items.HeaderFunc != nil {
+			rows, err := c.Items.HeaderFunc(ctx, file, header)
+			if err != nil {
+				return err
+			}
+			if err := c.Persist(ctx, rows); err != nil {
+				return err
+			}
+		}
+	}
+
+	f, err := fs.Open(file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	batchSize := ctx.batchSize()
+	batch := make([]interface{}, 0, batchSize)
+	index := 0
+	itemType := reflect.TypeOf(c.Items.NewItem())
+	itemsPath := c.Items.Key + "[]"
+	err = forEachItem(f, itemsKey, func(data json.RawMessage) error {
+		i := index
+		index++
+		ctx.recordDrift(&c.Files, version, itemsPath, itemType, data)
+
+		reasons, err := validationReasons(c.Items.Schema, data)
+		if err != nil {
+			return err
+		}
+		if len(reasons) > 0 {
+			if ctx.Strict {
+				return fmt.Errorf("invalid item %d: %s", i, strings.Join(reasons, "\n"))
+			}
+			return ctx.quarantine(&c.Files, file, itemPointer(itemsKey, i), data, reasons)
+		}
+
+		item := c.Items.NewItem()
+		if err := json.Unmarshal(data, item); err != nil {
+			return err
+		}
+		rows, err := c.Items.TransformFunc(ctx, file, header, item)
+		if err != nil {
+			return err
+		}
+
+		batch = append(batch, rows...)
+		if len(batch) >= batchSize {
+			if err := c.Persist(ctx, batch); err != nil {
+				return err
+			}
+			batch = make([]interface{}, 0, batchSize)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if len(batch) > 0 {
+		return c.Persist(ctx, batch)
+	}
+	return nil
+}
+
+// itemPointer returns the JSON pointer of the item of the index under the key.
+func itemPointer(key string, index int) string {
+	if key == "" {
+		return jsonPointer([]string{strconv.Itoa(index)})
+	}
+	return jsonPointer([]string{key, strconv.Itoa(index)})
+}
+
+// isItemsKey tells if the key is that of the items in any format.
+func (c *StreamCategory) isItemsKey(key string) bool {
+	if key == c.Items.Key {
+		return true
+	}
+	for _, v := range c.Files.Versions {
+		if v.Keys[key] == c.Items.Key {
+			return true
+		}
+	}
+	return false
+}
+
+// readHeader decodes the top-level object of the file with its items left out, and returns it with the
+// version of the file and the key of the items. The header is upgraded to the current format and validated
+// against the schema of the file as if there were no items, it is nil if the file is quarantined.
+func (c *StreamCategory) readHeader(ctx *ParseContext, fs afero.Fs, file string) (interface{}, *SchemaVersion, string, error) {
+	f, err := fs.Open(file)
+	if err != nil {
+		return nil, nil, "", err
+	}
+	defer f.Close()
+
+	dec := json.NewDecoder(f)
+	if err := expectDelim(dec, '{'); err != nil {
+		return nil, nil, "", err
+	}
+	keys := make([]string, 0)
+	itemsKey := c.Items.Key
+	fields := make(map[string]json.RawMessage)
+	for dec.More() {
+		t, err := dec.Token()
+		if err != nil {
+			return nil, nil, "", err
+		}
+		key := t.(string)
+		keys = append(keys, key)
+
+		if c.isItemsKey(key) {
+			if err := forEachArrayItem(dec, func(json.RawMessage) error { return nil }); err != nil {
+				return nil, nil, "", err
+			}
+			itemsKey = key
+			fields[key] = json.RawMessage("[]")
+			continue
+		}
+
+		var value json.RawMessage
+		if err := dec.Decode(&value); err != nil {
+			return nil, nil, "", err
+		}
+		fields[key] = value
+	}
+
+	version := c.Files.VersionOf(keys)
+	upgraded := make(map[string]json.RawMessage)
+	for key, value := range fields {
+		upgraded[version.currentKey(key)] = value
+	}
+	data, err := json.Marshal(upgraded)
+	if err != nil {
+		return nil, nil, "", err
+	}
+
+	ctx.recordDrift(&c.Files, version, "", c.RawType(), data)
+	if data, err = ctx.ValidateFile(&c.Files, file, data); err != nil || data == nil {
+		return nil, nil, "", err
+	}
+	header, err := c.Decode(data)
+	return header, version, itemsKey, err
+}
+
+// forEachItem calls fn with each item of the array under the key of the top-level object,
+// or of the top-level array if the key is empty. Only one item is held in memory at a time.
+func forEachItem(r io.Reader, key string, fn func(json.RawMessage) error) error {
+	dec := json.NewDecoder(r)
+	if key == "" {
+		return forEachArrayItem(dec, fn)
+	}
+
+	if err := expectDelim(dec, '{'); err != nil {
+		return err
+	}
+	for dec.More() {
+		t, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		if t == key {
+			return forEachArrayItem(dec, fn)
+		}
+
+		var value json.RawMessage
+		if err := dec.Decode(&value); err != nil {
+			return err
+		}
+	}
+	return fmt.Errorf("%s is not found", key)
+}
+
+func forEachArrayItem(dec *json.Decoder, fn func(json.RawMessage) error) error {
+	if err := expectDelim(dec, '['); err != nil {
+		return err
+	}
+	for dec.More() {
+		var item json.RawMessage
+		if err := dec.Decode(&item); err != nil {
+			return err
+		}
+		if err := fn(item); err != nil {
+			return err
+		}
+	}
+	return expectDelim(dec, ']')
+}
+
+func expectDelim(dec *json.Decoder, delim json.Delim) error {
+	t, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	if t != delim {
+		return fmt.Errorf("expected %s but found %v", delim, t)
+	}
+	return nil
+}
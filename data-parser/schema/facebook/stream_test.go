@@ -0,0 +1,106 @@
+package facebook
+
+import (
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+)
+
+func streamerOf(t *testing.T, name string) Streamer {
+	handlers, err := Handlers.Handlers(name)
+	assert.NoError(t, err)
+	s, ok := handlers[0].(Streamer)
+	assert.True(t, ok)
+	return s
+}
+
+func TestStreamInBatches(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	afero.WriteFile(fs, "comments/comments.json", []byte(`{"comments": [
+		{"timestamp": 1578201080, "title": "Me commented on a post."},
+		{"timestamp": 1578201090, "title": "Me commented on a post."},
+		{"timestamp": 1578201100, "title": "Me commented on a photo."}
+	]}`), 0644)
+
+	sink := &memorySink{}
+	ctx := NewParseContext(&sequence{}, "owner", "archive", sink, nil, "")
+	ctx.BatchSize = 2
+	assert.NoError(t, streamerOf(t, "comments").Stream(ctx, fs, "comments/comments.json"))
+	assert.Len(t, sink.rows, 3)
+	assert.Equal(t, 2, sink.batches)
+}
+
+func TestStreamGroupsBeforeComments(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	afero.WriteFile(fs, "comments/comments.json", []byte(`{"comments": [
+		{"timestamp": 1578201080, "title": "Me commented on a post."},
+		{"timestamp": 1578201090, "title": "Me commented on a post.", "data": [{"comment": {"timestamp": 1578201090, "comment": "hi", "group": "Gophers"}}]}
+	]}`), 0644)
+
+	sink := &memorySink{}
+	ctx := NewParseContext(&sequence{}, "owner", "archive", sink, nil, "")
+	assert.NoError(t, streamerOf(t, "comments").Stream(ctx, fs, "comments/comments.json"))
+	assert.Len(t, sink.rows, 3)
+	assert.Equal(t, "Gophers", sink.rows[0].(GroupORM).Name)
+	assert.Equal(t, sink.rows[0].(GroupORM).GroupID, *sink.rows[2].(CommentORM).GroupID)
+	assert.Equal(t, 2, sink.batches)
+}
+
+func TestStreamTopLevelArray(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	afero.WriteFile(fs, "posts/your_posts_1.json", []byte(`[
+		{"timestamp": 1578201080, "data": [{"post": "hello"}]},
+		{"timestamp": 1578201090, "data": [{"post": "world"}]}
+	]`), 0644)
+
+	sink := &memorySink{}
+	ctx := NewParseContext(&sequence{}, "owner", "archive", sink, nil, "")
+	assert.NoError(t, streamerOf(t, "posts").Stream(ctx, fs, "posts/your_posts_1.json"))
+	if assert.Len(t, sink.rows, 2) {
+		assert.Equal(t, "hello", sink.rows[0].(Post).Post)
+		assert.Equal(t, "world", sink.rows[1].(Post).Post)
+	}
+}
+
+func TestStreamWithHeader(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	// the header comes after the messages in the export
+	afero.WriteFile(fs, "messages/inbox/alice/message_1.json", []byte(`{
+		"participants": [{"name": "Alice"}, {"name": "Me"}],
+		"messages": [
+			{"sender_name": "Alice", "timestamp_ms": 1578201080000, "content": "hi", "type": "Generic"},
+			{"sender_name": "Me", "timestamp_ms": 1578201090000, "content": "hello", "type": "Generic"}
+		],
+		"title": "Alice",
+		"is_still_participant": true,
+		"thread_type": "Regular",
+		"thread_path": "inbox/alice"
+	}`), 0644)
+
+	sink := &memorySink{}
+	ctx := NewParseContext(&sequence{}, "owner", "archive", sink, nil, "")
+	assert.NoError(t, streamerOf(t, "messages").Stream(ctx, fs, "messages/inbox/alice/message_1.json"))
+	if assert.Len(t, sink.rows, 5) {
+		conversation := sink.rows[0].(ConversationORM)
+		assert.Equal(t, "Alice", conversation.Title)
+		assert.Equal(t, conversation.ConversationID, sink.rows[3].(MessageORM).ConversationID)
+		assert.Equal(t, "hello", sink.rows[4].(MessageORM).Content)
+	}
+}
+
+func TestStreamInvalidItem(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	afero.WriteFile(fs, "comments/comments.json", []byte(`{"comments": [
+		{"timestamp": 1578201080, "title": "Me commented on a post."},
+		{"timestamp": 1578201090}
+	]}`), 0644)
+
+	ctx := NewParseContext(&sequence{}, "owner", "archive", &memorySink{}, nil, "")
+	ctx.Strict = true
+	err := streamerOf(t, "comments").Stream(ctx, fs, "comments/comments.json")
+	assert.Contains(t, err.Error(), "invalid item 1")
+
+	afero.WriteFile(fs, "comments/comments.json", []byte(`{"comments": [], "unknown": 1}`), 0644)
+	assert.Error(t, streamerOf(t, "comments").Stream(ctx, fs, "comments/comments.json"))
+}
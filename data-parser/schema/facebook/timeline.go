@@ -0,0 +1,83 @@
+package facebook
+
+import (
+	"strings"
+	"time"
+
+	"github.com/xeipuuv/gojsonschema"
+)
+
+type RawTimelinePosts struct {
+	WallPostsSentToYou *TimelinePostLog `json:"wall_posts_sent_to_you" jsonschema:"required"`
+}
+
+type TimelinePostLog struct {
+	ActivityLogData []*RawPost `json:"activity_log_data" jsonschema:"required"`
+}
+
+func TimelinePostSchemaLoader() *gojsonschema.Schema {
+	return SchemaLoaderOf(&RawTimelinePosts{})
+}
+
+// TimelinePostORM is a post written by another person on the timeline of the data owner.
+type TimelinePostORM struct {
+	PostID                int64
+	Author                string
+	Timestamp             int
+	Date                  string
+	Weekday               int
+	Title                 string
+	Post                  string
+	ExternalContextURL    string
+	ExternalContextSource string
+	ExternalContextName   string
+	MediaURI              string
+	DataOwnerID           string
+	ArchiveID             string
+}
+
+func (TimelinePostORM) TableName() string {
+	return "posts_timelinepost"
+}
+
+// ORM returns the posts of others, whose authors are taken from titles such as "Alice wrote on your timeline.".
+func (r RawTimelinePosts) ORM(ids IDGenerator, owner, archiveID string) []interface{} {
+	result := make([]interface{}, 0)
+	for _, rp := range r.WallPostsSentToYou.ActivityLogData {
+		t := time.Unix(int64(rp.Timestamp), 0)
+		title := string(rp.Title)
+		orm := TimelinePostORM{
+			PostID:      ids.NextID(),
+			Timestamp:   rp.Timestamp,
+			Date:        DateOfTime(t),
+			Weekday:     WeekdayOfTime(t),
+			Title:       title,
+			DataOwnerID: owner,
+			ArchiveID:   archiveID,
+		}
+		for _, suffix := range []string{" wrote on your timeline.", " posted on your timeline."} {
+			if strings.HasSuffix(title, suffix) {
+				orm.Author = strings.TrimSuffix(title, suffix)
+			}
+		}
+		for _, d := range rp.Data {
+			if d.Post != "" {
+				orm.Post = string(d.Post)
+			}
+		}
+		for _, a := range rp.Attachments {
+			for _, item := range a.Data {
+				if item.ExternalContext != nil {
+					orm.ExternalContextName = string(item.ExternalContext.Name)
+					orm.ExternalContextSource = string(item.ExternalContext.Source)
+					orm.ExternalContextURL = string(item.ExternalContext.URL)
+				}
+				if item.Media != nil {
+					orm.MediaURI = MediaKey(owner, archiveID, item.Media.URI)
+				}
+			}
+		}
+		result = append(result, orm)
+	}
+	return result
+}
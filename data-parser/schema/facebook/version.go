@@ -0,0 +1,112 @@
+package facebook
+
+import (
+	"encoding/json"
+	"sort"
+)
+
+// CurrentSchemaVersion is the name of the format that the raw types are defined for.
+const CurrentSchemaVersion = "current"
+
+// SchemaVersion is a format of the files of a pattern other than the current one.
+// Files in such a format are upgraded to the current one before they are validated and decoded.
+type SchemaVersion struct {
+	Name string
+	// Keys maps the top-level keys of this format to those of the current one,
+	// a file having any of the keys is in this format.
+	Keys map[string]string
+}
+
+// The v2 formats suffix the top-level keys with _v2, while the items are the same.
+var (
+	FriendsV2          = SchemaVersion{Name: "v2", Keys: map[string]string{"friends_v2": "friends"}}
+	FriendshipEventsV2 = SchemaVersion{Name: "v2", Keys: map[string]string{
+		"sent_requests_v2":     "sent_requests",
+		"received_requests_v2": "received_requests",
+		"rejected_requests_v2": "rejected_requests",
+		"deleted_friends_v2":   "deleted_friends",
+	}}
+	CommentsV2 = SchemaVersion{Name: "v2", Keys: map[string]string{"comments_v2": "comments"}}
+)
+
+// VersionName returns the name of the version, which is the current one if it's nil.
+func (v *SchemaVersion) VersionName() string {
+	if v == nil {
+		return CurrentSchemaVersion
+	}
+	return v.Name
+}
+
+// currentKey returns the key of the current format for the key of this one.
+func (v *SchemaVersion) currentKey(key string) string {
+	if v == nil {
+		return key
+	}
+	if current, ok := v.Keys[key]; ok {
+		return current
+	}
+	return key
+}
+
+// VersionOf selects the version of a file by the top-level keys of the file.
+// It returns nil if the file is in the current format.
+func (p *Pattern) VersionOf(keys []string) *SchemaVersion {
+	for i, v := range p.Versions {
+		for _, key := range keys {
+			if _, ok := v.Keys[key]; ok {
+				return &p.Versions[i]
+			}
+		}
+	}
+	return nil
+}
+
+// PrepareFile upgrades the file to the current format of the pattern of the handler, records how it drifts
+// from the raw type of the handler, and then validates it as ValidateFile does.
+func (ctx *ParseContext) PrepareFile(h Handler, file string, data []byte) ([]byte, error) {
+	data, version, err := ctx.UpgradeFile(h.Pattern(), data)
+	if err != nil {
+		return nil, err
+	}
+	ctx.recordDrift(h.Pattern(), version, "", h.RawType(), data)
+	return ctx.ValidateFile(h.Pattern(), file, data)
+}
+
+// UpgradeFile renames the top-level keys of a file in another format to those of the current one.
+// Files other than JSON objects are returned as they are.
+func (ctx *ParseContext) UpgradeFile(p *Pattern, data []byte) ([]byte, *SchemaVersion, error) {
+	if len(p.Versions) == 0 {
+		return data, nil, nil
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return data, nil, nil
+	}
+	keys := make([]string, 0, len(fields))
+	for key := range fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	v := p.VersionOf(keys)
+	renamed := make(map[string]json.RawMessage)
+	changed := false
+	for key, value := range fields {
+		renamed[v.currentKey(key)] = value
+		changed = changed || v.currentKey(key) != key
+	}
+	if !changed {
+		return data, v, nil
+	}
+	if len(renamed) != len(fields) {
+		// both formats of a key are found, the file is left for the validation to report
+		return data, v, nil
+	}
+
+	upgraded, err := json.Marshal(renamed)
+	if err != nil {
+		return nil, nil, err
+	}
+	return upgraded, v, nil
+}
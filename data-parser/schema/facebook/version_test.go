@@ -0,0 +1,57 @@
+package facebook
+
+import (
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVersionOf(t *testing.T) {
+	v3 := SchemaVersion{Name: "v3", Keys: map[string]string{"friends_v3": "friends"}}
+	v2 := SchemaVersion{Name: "v2", Keys: map[string]string{"friends_v2": "friends"}}
+	p := &Pattern{Name: "friends", Versions: []SchemaVersion{v3, v2}}
+
+	assert.Equal(t, "v2", p.VersionOf([]string{"friends_v2"}).VersionName())
+	assert.Equal(t, "v3", p.VersionOf([]string{"friends_v3", "other"}).VersionName())
+	assert.Nil(t, p.VersionOf([]string{"friends"}))
+	assert.Nil(t, p.VersionOf(nil))
+}
+
+func TestUpgradeFile(t *testing.T) {
+	ctx := NewParseContext(&sequence{}, "owner", "archive", &memorySink{}, nil, "")
+
+	data, version, err := ctx.UpgradeFile(&FriendsPattern, []byte(`{"friends_v2": [{"name": "Alice", "timestamp": 1578201080}]}`))
+	assert.NoError(t, err)
+	assert.Equal(t, "v2", version.VersionName())
+	assert.JSONEq(t, `{"friends": [{"name": "Alice", "timestamp": 1578201080}]}`, string(data))
+
+	data, version, err = ctx.UpgradeFile(&FriendsPattern, []byte(`{"friends": []}`))
+	assert.NoError(t, err)
+	assert.Nil(t, version)
+	assert.Equal(t, `{"friends": []}`, string(data))
+}
+
+func TestStreamVersionedFile(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	afero.WriteFile(fs, "comments/comments.json", []byte(`{"comments_v2": [
+		{"timestamp": 1578201080, "title": "Me commented on a post."},
+		{"timestamp": 1578201090, "title": "Me commented on a post.", "author": "Me"}
+	]}`), 0644)
+
+	sink := &memorySink{}
+	ctx := NewParseContext(&sequence{}, "owner", "archive", sink, nil, "")
+	assert.NoError(t, streamerOf(t, "comments").Stream(ctx, fs, "comments/comments.json"))
+	assert.Len(t, sink.rows, 1)
+	if assert.Len(t, ctx.Quarantined, 1) {
+		assert.Equal(t, "/comments_v2/1", ctx.Quarantined[0].(QuarantineORM).Pointer)
+	}
+
+	drifts := ctx.Drifts.ORM("owner", "archive")
+	if assert.Len(t, drifts, 1) {
+		drift := drifts[0].(DriftORM)
+		assert.Equal(t, "v2", drift.SchemaVersion)
+		assert.Equal(t, "comments[].author", drift.Path)
+		assert.Equal(t, 1, drift.Count)
+	}
+}
@@ -0,0 +1,41 @@
+package instagram
+
+import (
+	"github.com/xeipuuv/gojsonschema"
+
+	"github.com/bitmark-inc/datapod/data-parser/schema/facebook"
+)
+
+// RawComments has comments in the form of [time, comment, author of the commented media].
+type RawComments struct {
+	MediaComments [][]string `json:"media_comments"`
+	LiveComments  [][]string `json:"live_comments"`
+}
+
+func CommentsSchemaLoader() *gojsonschema.Schema {
+	return facebook.SchemaLoaderOf(&RawComments{})
+}
+
+func (r RawComments) ORM(ids facebook.IDGenerator, owner, archiveID string) []interface{} {
+	result := make([]interface{}, 0)
+	for _, c := range append(r.MediaComments, r.LiveComments...) {
+		if len(c) < 2 {
+			continue
+		}
+		t := parseTime(c[0])
+		orm := facebook.CommentORM{
+			CommentsID:  ids.NextID(),
+			Timestamp:   timestamp(c[0]),
+			Comment:     c[1],
+			Date:        facebook.DateOfTime(t),
+			Weekday:     facebook.WeekdayOfTime(t),
+			DataOwnerID: owner,
+			ArchiveID:   archiveID,
+		}
+		if len(c) > 2 {
+			orm.Author = c[2]
+		}
+		result = append(result, orm)
+	}
+	return result
+}
@@ -0,0 +1,52 @@
+package instagram
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/bitmark-inc/datapod/data-parser/schema/facebook"
+)
+
+func TestCommentsORM(t *testing.T) {
+	data := []byte(`{
+		"media_comments":[["2019-08-12T10:00:00+00:00","nice shot","alice"],["2019-08-12T11:00:00"]],
+		"live_comments":[["2019-08-13T10:00:00+00:00","hello"]]
+	}`)
+	assert.NoError(t, CommentsPattern.Validate(data))
+
+	var raw RawComments
+	assert.NoError(t, json.Unmarshal(data, &raw))
+	comments := raw.ORM(&sequence{}, "owner", "archive")
+
+	// a comment without its text is left out
+	assert.Len(t, comments, 2)
+	nice := comments[0].(facebook.CommentORM)
+	assert.Equal(t, "nice shot", nice.Comment)
+	assert.Equal(t, "alice", nice.Author)
+	assert.Equal(t, 1565604000, nice.Timestamp)
+	assert.Equal(t, "owner", nice.DataOwnerID)
+	live := comments[1].(facebook.CommentORM)
+	assert.Equal(t, "hello", live.Comment)
+	assert.Empty(t, live.Author)
+	assert.Nil(t, live.GroupID)
+}
+
+func TestLikesORM(t *testing.T) {
+	data := []byte(`{
+		"media_likes":[["2019-08-12T10:00:00+00:00","alice"]],
+		"comment_likes":[["2019-08-13T10:00:00+00:00","bob"],["2019-08-14T10:00:00+00:00"]]
+	}`)
+	assert.NoError(t, LikesPattern.Validate(data))
+
+	var raw RawLikes
+	assert.NoError(t, json.Unmarshal(data, &raw))
+	likes := raw.ORM(&sequence{}, "owner", "archive")
+
+	assert.Len(t, likes, 2)
+	assert.Equal(t, "You liked alice's post.", likes[0].(facebook.ReactionORM).Title)
+	assert.Equal(t, "LIKE", likes[0].(facebook.ReactionORM).Reaction)
+	assert.Equal(t, 1565604000, likes[0].(facebook.ReactionORM).Timestamp)
+	assert.Equal(t, "You liked bob's comment.", likes[1].(facebook.ReactionORM).Title)
+}
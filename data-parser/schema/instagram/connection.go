@@ -0,0 +1,72 @@
+package instagram
+
+import (
+	"sort"
+
+	"github.com/xeipuuv/gojsonschema"
+
+	"github.com/bitmark-inc/datapod/data-parser/schema/facebook"
+)
+
+// RawConnections maps usernames to the time of each connection.
+type RawConnections struct {
+	BlockedUsers            map[string]string `json:"blocked_users"`
+	RestrictedUsers         map[string]string `json:"restricted_users"`
+	FollowRequestsSent      map[string]string `json:"follow_requests_sent"`
+	Followers               map[string]string `json:"followers"`
+	Following               map[string]string `json:"following"`
+	FollowingHashtags       map[string]string `json:"following_hashtags"`
+	DismissedSuggestedUsers map[string]string `json:"dismissed_suggested_users"`
+	CloseFriends            map[string]string `json:"close_friends"`
+}
+
+func ConnectionsSchemaLoader() *gojsonschema.Schema {
+	return facebook.SchemaLoaderOf(&RawConnections{})
+}
+
+func sortedUsernames(connections map[string]string) []string {
+	usernames := make([]string, 0, len(connections))
+	for username := range connections {
+		usernames = append(usernames, username)
+	}
+	sort.Strings(usernames)
+	return usernames
+}
+
+// FriendsORM returns the close friends, which are the friends of Instagram.
+func (r RawConnections) FriendsORM(ids facebook.IDGenerator, owner, archiveID string) []interface{} {
+	result := make([]interface{}, 0)
+	for _, username := range sortedUsernames(r.CloseFriends) {
+		result = append(result, facebook.FriendORM{
+			FriendID:    ids.NextID(),
+			FriendName:  username,
+			Timestamp:   timestamp(r.CloseFriends[username]),
+			DataOwnerID: owner,
+			ArchiveID:   archiveID,
+		})
+	}
+	return result
+}
+
+// FollowsORM returns the followers and the followed users.
+func (r RawConnections) FollowsORM(owner, archiveID string) []interface{} {
+	result := make([]interface{}, 0)
+	for _, c := range []struct {
+		connections map[string]string
+		followType  string
+	}{
+		{r.Followers, facebook.FollowTypeFollower},
+		{r.Following, facebook.FollowTypeFollowing},
+	} {
+		for _, username := range sortedUsernames(c.connections) {
+			result = append(result, facebook.FollowORM{
+				Name:        username,
+				Timestamp:   timestamp(c.connections[username]),
+				Type:        c.followType,
+				DataOwnerID: owner,
+				ArchiveID:   archiveID,
+			})
+		}
+	}
+	return result
+}
@@ -0,0 +1,37 @@
+package instagram
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/bitmark-inc/datapod/data-parser/schema/facebook"
+)
+
+func TestConnectionsORM(t *testing.T) {
+	data := []byte(`{
+		"close_friends":{"carol":"2019-08-12T10:00:00+00:00","alice":"2019-08-11T10:00:00+00:00"},
+		"followers":{"bob":"2019-08-10T10:00:00+00:00"},
+		"following":{"alice":"2019-08-09T10:00:00+00:00","dave":"2019-08-08T10:00:00"},
+		"blocked_users":{"eve":"2019-08-07T10:00:00+00:00"}
+	}`)
+	assert.NoError(t, ConnectionsPattern.Validate(data))
+
+	var raw RawConnections
+	assert.NoError(t, json.Unmarshal(data, &raw))
+
+	// only close friends are friends, in the order of their usernames
+	friends := raw.FriendsORM(&sequence{}, "owner", "archive")
+	assert.Equal(t, []interface{}{
+		facebook.FriendORM{FriendID: 1, FriendName: "alice", Timestamp: 1565517600, DataOwnerID: "owner", ArchiveID: "archive"},
+		facebook.FriendORM{FriendID: 2, FriendName: "carol", Timestamp: 1565604000, DataOwnerID: "owner", ArchiveID: "archive"},
+	}, friends)
+
+	follows := raw.FollowsORM("owner", "archive")
+	assert.Equal(t, []interface{}{
+		facebook.FollowORM{Name: "bob", Timestamp: 1565431200, Type: facebook.FollowTypeFollower, DataOwnerID: "owner", ArchiveID: "archive"},
+		facebook.FollowORM{Name: "alice", Timestamp: 1565344800, Type: facebook.FollowTypeFollowing, DataOwnerID: "owner", ArchiveID: "archive"},
+		facebook.FollowORM{Name: "dave", Timestamp: 1565258400, Type: facebook.FollowTypeFollowing, DataOwnerID: "owner", ArchiveID: "archive"},
+	}, follows)
+}
@@ -0,0 +1,27 @@
+package instagram
+
+import (
+	"time"
+)
+
+// Instagram exports times in ISO 8601, with or without the time zone.
+var timeLayouts = []string{time.RFC3339, "2006-01-02T15:04:05"}
+
+// parseTime returns zero time if the value is not in any known layouts.
+func parseTime(value string) time.Time {
+	for _, layout := range timeLayouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// timestamp is the unix time of the value, or 0 if the value is invalid.
+func timestamp(value string) int {
+	t := parseTime(value)
+	if t.IsZero() {
+		return 0
+	}
+	return int(t.Unix())
+}
@@ -0,0 +1,45 @@
+package instagram
+
+import (
+	"fmt"
+
+	"github.com/xeipuuv/gojsonschema"
+
+	"github.com/bitmark-inc/datapod/data-parser/schema/facebook"
+)
+
+// RawLikes has likes in the form of [time, author of the liked media or comment].
+type RawLikes struct {
+	MediaLikes   [][]string `json:"media_likes"`
+	CommentLikes [][]string `json:"comment_likes"`
+}
+
+func LikesSchemaLoader() *gojsonschema.Schema {
+	return facebook.SchemaLoaderOf(&RawLikes{})
+}
+
+func likesORM(ids facebook.IDGenerator, likes [][]string, object, owner, archiveID string) []interface{} {
+	result := make([]interface{}, 0)
+	for _, l := range likes {
+		if len(l) < 2 {
+			continue
+		}
+		t := parseTime(l[0])
+		result = append(result, facebook.ReactionORM{
+			ReactionID:  ids.NextID(),
+			Timestamp:   timestamp(l[0]),
+			Date:        facebook.DateOfTime(t),
+			Weekday:     facebook.WeekdayOfTime(t),
+			Title:       fmt.Sprintf("You liked %s's %s.", l[1], object),
+			Reaction:    "LIKE",
+			DataOwnerID: owner,
+			ArchiveID:   archiveID,
+		})
+	}
+	return result
+}
+
+func (r RawLikes) ORM(ids facebook.IDGenerator, owner, archiveID string) []interface{} {
+	result := likesORM(ids, r.MediaLikes, "post", owner, archiveID)
+	return append(result, likesORM(ids, r.CommentLikes, "comment", owner, archiveID)...)
+}
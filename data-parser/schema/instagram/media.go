@@ -0,0 +1,87 @@
+package instagram
+
+import (
+	"path/filepath"
+
+	"github.com/xeipuuv/gojsonschema"
+
+	"github.com/bitmark-inc/datapod/data-parser/schema/facebook"
+)
+
+type RawMedia struct {
+	Photos  []*Media `json:"photos"`
+	Videos  []*Media `json:"videos"`
+	Stories []*Media `json:"stories"`
+	Profile []*Media `json:"profile"`
+	Direct  []*Media `json:"direct"`
+}
+
+type Media struct {
+	Caption  string `json:"caption"`
+	TakenAt  string `json:"taken_at" jsonschema:"required"`
+	Location string `json:"location"`
+	Path     string `json:"path" jsonschema:"required"`
+}
+
+func MediaSchemaLoader() *gojsonschema.Schema {
+	return facebook.SchemaLoaderOf(&RawMedia{})
+}
+
+// ORM returns photos and videos as posts with media and stories as stories.
+// Posts with media are returned separately since they are created with their associations.
+func (r RawMedia) ORM(ids facebook.IDGenerator, owner, archiveID string) ([]interface{}, []facebook.Post) {
+	rows := make([]interface{}, 0)
+	posts := make([]facebook.Post, 0)
+
+	for _, m := range append(r.Photos, r.Videos...) {
+		t := parseTime(m.TakenAt)
+		post := facebook.Post{
+			PostID:        ids.NextID(),
+			Timestamp:     timestamp(m.TakenAt),
+			Date:          facebook.DateOfTime(t),
+			Weekday:       facebook.WeekdayOfTime(t),
+			Post:          m.Caption,
+			MediaAttached: true,
+			DataOwnerID:   owner,
+			ArchiveID:     archiveID,
+			MediaItems: []facebook.PostMedia{
+				{
+					PMID:              ids.NextID(),
+					MediaURI:          facebook.MediaKey(owner, archiveID, facebook.MojibakeString(m.Path)),
+					FilenameExtension: filepath.Ext(m.Path),
+					DataOwnerID:       owner,
+					ArchiveID:         archiveID,
+				},
+			},
+		}
+		if m.Location != "" {
+			post.Places = []facebook.Place{
+				{
+					PPID:        ids.NextID(),
+					Name:        m.Location,
+					Timestamp:   post.Timestamp,
+					Date:        post.Date,
+					Source:      facebook.PlaceSourceCheckIn,
+					DataOwnerID: owner,
+					ArchiveID:   archiveID,
+				},
+			}
+		}
+		posts = append(posts, post)
+	}
+
+	for _, m := range r.Stories {
+		rows = append(rows, facebook.StoryORM{
+			StoryID:           ids.NextID(),
+			Type:              facebook.StoryTypeArchived,
+			Title:             m.Caption,
+			MediaURI:          facebook.MediaKey(owner, archiveID, facebook.MojibakeString(m.Path)),
+			FilenameExtension: filepath.Ext(m.Path),
+			Timestamp:         timestamp(m.TakenAt),
+			DataOwnerID:       owner,
+			ArchiveID:         archiveID,
+		})
+	}
+
+	return rows, posts
+}
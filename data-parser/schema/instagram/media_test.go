@@ -0,0 +1,52 @@
+package instagram
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/bitmark-inc/datapod/data-parser/schema/facebook"
+)
+
+func TestMediaORM(t *testing.T) {
+	data := []byte(`{
+		"photos":[
+			{"caption":"sunset","taken_at":"2019-08-12T10:00:00+00:00","location":"Taipei 101","path":"photos/201908/p.jpg"},
+			{"caption":"","taken_at":"2019-08-12T11:00:00+00:00","path":"photos/201908/q.jpg"}
+		],
+		"videos":[{"caption":"waves","taken_at":"2019-08-13T10:00:00+00:00","path":"videos/201908/v.mp4"}],
+		"stories":[{"caption":"hi","taken_at":"2019-08-14T10:00:00+00:00","path":"stories/201908/s.mp4"}],
+		"profile":[{"caption":"","taken_at":"2019-08-15T10:00:00+00:00","path":"profile/201908/me.jpg"}]
+	}`)
+	assert.NoError(t, MediaPattern.Validate(data))
+	// the path of a media is required
+	assert.Error(t, MediaPattern.Validate([]byte(`{"photos":[{"caption":"sunset","taken_at":"2019-08-12T10:00:00+00:00"}]}`)))
+
+	var raw RawMedia
+	assert.NoError(t, json.Unmarshal(data, &raw))
+	rows, posts := raw.ORM(&sequence{}, "owner", "archive")
+
+	// photos and videos are posts, profile pictures are left out
+	assert.Len(t, posts, 3)
+	sunset := posts[0]
+	assert.Equal(t, "sunset", sunset.Post)
+	assert.Equal(t, 1565604000, sunset.Timestamp)
+	assert.True(t, sunset.MediaAttached)
+	assert.Len(t, sunset.MediaItems, 1)
+	assert.Equal(t, "owner/fb_archives/archive/photos/201908/p.jpg", sunset.MediaItems[0].MediaURI)
+	assert.Equal(t, ".jpg", sunset.MediaItems[0].FilenameExtension)
+	assert.Len(t, sunset.Places, 1)
+	assert.Equal(t, "Taipei 101", sunset.Places[0].Name)
+	assert.Equal(t, facebook.PlaceSourceCheckIn, sunset.Places[0].Source)
+	assert.Equal(t, sunset.Timestamp, sunset.Places[0].Timestamp)
+	assert.Empty(t, posts[1].Places)
+	assert.Equal(t, ".mp4", posts[2].MediaItems[0].FilenameExtension)
+
+	assert.Len(t, rows, 1)
+	story := rows[0].(facebook.StoryORM)
+	assert.Equal(t, facebook.StoryTypeArchived, story.Type)
+	assert.Equal(t, "hi", story.Title)
+	assert.Equal(t, "owner/fb_archives/archive/stories/201908/s.mp4", story.MediaURI)
+	assert.Equal(t, 1565776800, story.Timestamp)
+}
@@ -0,0 +1,115 @@
+package instagram
+
+import (
+	"path/filepath"
+	"strings"
+
+	"github.com/xeipuuv/gojsonschema"
+
+	"github.com/bitmark-inc/datapod/data-parser/schema/facebook"
+)
+
+type RawConversations struct {
+	Items []*Conversation
+}
+
+type Conversation struct {
+	Participants []string   `json:"participants" jsonschema:"required"`
+	Conversation []*Message `json:"conversation" jsonschema:"required"`
+}
+
+type Message struct {
+	Sender        string         `json:"sender" jsonschema:"required"`
+	CreatedAt     string         `json:"created_at" jsonschema:"required"`
+	Text          string         `json:"text"`
+	Link          string         `json:"link"`
+	Media         string         `json:"media"`
+	MediaURL      string         `json:"media_url"`
+	MediaOwner    string         `json:"media_owner"`
+	MediaShareURL string         `json:"media_share_url"`
+	StoryShare    string         `json:"story_share"`
+	Heart         string         `json:"heart"`
+	Likes         []*MessageLike `json:"likes"`
+}
+
+type MessageLike struct {
+	Username string `json:"username" jsonschema:"required"`
+	Date     string `json:"date"`
+}
+
+func ConversationArraySchemaLoader() *gojsonschema.Schema {
+	return facebook.ArraySchemaLoaderOf(&Conversation{})
+}
+
+// ORM returns the conversations in the messages tables of Facebook.
+func (r RawConversations) ORM(ids facebook.IDGenerator, owner, archiveID string) []interface{} {
+	result := make([]interface{}, 0)
+	for _, c := range r.Items {
+		conversationID := ids.NextID()
+		result = append(result, facebook.ConversationORM{
+			ConversationID:     conversationID,
+			Title:              strings.Join(c.Participants, ", "),
+			ThreadType:         "Instagram",
+			IsStillParticipant: true,
+			DataOwnerID:        owner,
+			ArchiveID:          archiveID,
+		})
+		for _, p := range c.Participants {
+			result = append(result, facebook.ParticipantORM{
+				ConversationID: conversationID,
+				Name:           p,
+				DataOwnerID:    owner,
+				ArchiveID:      archiveID,
+			})
+		}
+
+		for _, m := range c.Conversation {
+			t := parseTime(m.CreatedAt)
+			message := facebook.MessageORM{
+				MessageID:      ids.NextID(),
+				ConversationID: conversationID,
+				SenderName:     m.Sender,
+				TimestampMS:    t.UnixNano() / 1e6,
+				Date:           facebook.DateOfTime(t),
+				Weekday:        facebook.WeekdayOfTime(t),
+				Type:           "Generic",
+				Content:        m.Text,
+				ShareLink:      m.Link,
+				DataOwnerID:    owner,
+				ArchiveID:      archiveID,
+			}
+			if t.IsZero() {
+				message.TimestampMS = 0
+			}
+			if m.Heart != "" {
+				message.Content = m.Heart
+			}
+			if message.ShareLink == "" {
+				message.ShareLink = m.MediaShareURL
+			}
+			result = append(result, message)
+
+			if m.Media != "" {
+				result = append(result, facebook.MessageAttachmentORM{
+					AttachmentID:      ids.NextID(),
+					MessageID:         message.MessageID,
+					Type:              "photo",
+					MediaURI:          facebook.MediaKey(owner, archiveID, facebook.MojibakeString(m.Media)),
+					FilenameExtension: filepath.Ext(m.Media),
+					DataOwnerID:       owner,
+					ArchiveID:         archiveID,
+				})
+			}
+			for _, l := range m.Likes {
+				result = append(result, facebook.MessageReactionORM{
+					MessageID:   message.MessageID,
+					Reaction:    "❤",
+					Actor:       l.Username,
+					DataOwnerID: owner,
+					ArchiveID:   archiveID,
+				})
+			}
+		}
+	}
+	return result
+}
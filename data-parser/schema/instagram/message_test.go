@@ -0,0 +1,50 @@
+package instagram
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/bitmark-inc/datapod/data-parser/schema/facebook"
+)
+
+func TestConversationsORM(t *testing.T) {
+	data := []byte(`[{"participants":["me","alice"],"conversation":[
+		{"sender":"alice","created_at":"2019-08-12T10:00:00+00:00","text":"hi","likes":[{"username":"me","date":"2019-08-12T10:01:00+00:00"}]},
+		{"sender":"me","created_at":"2019-08-12T10:02:00+00:00","heart":"❤️"},
+		{"sender":"me","created_at":"2019-08-12T10:03:00+00:00","media":"direct/201908/d.jpg"},
+		{"sender":"alice","created_at":"2019-08-12T10:04:00+00:00","media_share_url":"https://example.com/p/1"}
+	]}]`)
+	assert.NoError(t, MessagesPattern.Validate(data))
+
+	var items []*Conversation
+	assert.NoError(t, json.Unmarshal(data, &items))
+	rows := RawConversations{Items: items}.ORM(&sequence{}, "owner", "archive")
+
+	conversation := rows[0].(facebook.ConversationORM)
+	assert.Equal(t, "me, alice", conversation.Title)
+	assert.Equal(t, "Instagram", conversation.ThreadType)
+	assert.Equal(t, "me", rows[1].(facebook.ParticipantORM).Name)
+	assert.Equal(t, "alice", rows[2].(facebook.ParticipantORM).Name)
+
+	hi := rows[3].(facebook.MessageORM)
+	assert.Equal(t, conversation.ConversationID, hi.ConversationID)
+	assert.Equal(t, "alice", hi.SenderName)
+	assert.Equal(t, "hi", hi.Content)
+	assert.Equal(t, int64(1565604000000), hi.TimestampMS)
+	like := rows[4].(facebook.MessageReactionORM)
+	assert.Equal(t, hi.MessageID, like.MessageID)
+	assert.Equal(t, "me", like.Actor)
+
+	assert.Equal(t, "❤️", rows[5].(facebook.MessageORM).Content)
+
+	photo := rows[6].(facebook.MessageORM)
+	attachment := rows[7].(facebook.MessageAttachmentORM)
+	assert.Equal(t, photo.MessageID, attachment.MessageID)
+	assert.Equal(t, "owner/fb_archives/archive/direct/201908/d.jpg", attachment.MediaURI)
+	assert.Equal(t, ".jpg", attachment.FilenameExtension)
+
+	assert.Equal(t, "https://example.com/p/1", rows[8].(facebook.MessageORM).ShareLink)
+	assert.Len(t, rows, 9)
+}
@@ -0,0 +1,38 @@
+package instagram
+
+import (
+	"regexp"
+
+	"github.com/bitmark-inc/datapod/data-parser/schema/facebook"
+)
+
+// The JSON files are at the root of an Instagram archive, so the location of a pattern is the file itself.
+var (
+	ProfilePattern     = facebook.Pattern{Name: "instagram_profile", Location: "profile.json", Regexp: regexp.MustCompile("^profile.json$"), Schema: ProfileSchemaLoader()}
+	ConnectionsPattern = facebook.Pattern{Name: "instagram_connections", Location: "connections.json", Regexp: regexp.MustCompile("^connections.json$"), Schema: ConnectionsSchemaLoader()}
+	MediaPattern       = facebook.Pattern{Name: "instagram_media", Location: "media.json", Regexp: regexp.MustCompile("^media.json$"), Schema: MediaSchemaLoader()}
+	CommentsPattern    = facebook.Pattern{Name: "instagram_comments", Location: "comments.json", Regexp: regexp.MustCompile("^comments.json$"), Schema: CommentsSchemaLoader()}
+	LikesPattern       = facebook.Pattern{Name: "instagram_likes", Location: "likes.json", Regexp: regexp.MustCompile("^likes.json$"), Schema: LikesSchemaLoader()}
+	MessagesPattern    = facebook.Pattern{Name: "instagram_messages", Location: "messages.json", Regexp: regexp.MustCompile("^messages.json$"), Schema: ConversationArraySchemaLoader()}
+	SearchesPattern    = facebook.Pattern{Name: "instagram_searches", Location: "searches.json", Regexp: regexp.MustCompile("^searches.json$"), Schema: SearchArraySchemaLoader()}
+	// media files are uploaded as they are
+	PhotosPattern  = facebook.Pattern{Name: "instagram_photos", Location: "photos"}
+	VideosPattern  = facebook.Pattern{Name: "instagram_videos", Location: "videos"}
+	StoriesPattern = facebook.Pattern{Name: "instagram_stories", Location: "stories"}
+	DirectPattern  = facebook.Pattern{Name: "instagram_direct", Location: "direct"}
+)
+
+// IsArchive tells if the files of an archive are an Instagram export,
+// which has profile.json and media.json at its root.
+func IsArchive(names []string) bool {
+	var profile, media bool
+	for _, name := range names {
+		switch name {
+		case "profile.json":
+			profile = true
+		case "media.json":
+			media = true
+		}
+	}
+	return profile && media
+}
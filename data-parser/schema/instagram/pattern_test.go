@@ -0,0 +1,57 @@
+package instagram
+
+import (
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/bitmark-inc/datapod/data-parser/schema/facebook"
+)
+
+func TestIsArchive(t *testing.T) {
+	assert.True(t, IsArchive([]string{"comments.json", "media.json", "photos/201908/p.jpg", "profile.json"}))
+	assert.False(t, IsArchive([]string{"profile_information/profile_information.json", "photos_and_videos/album/0.json"}))
+	assert.False(t, IsArchive([]string{"profile.json"}))
+}
+
+func TestRootPatterns(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	afero.WriteFile(fs, "/tmp/user-a/data/media.json", []byte(`{"photos":[{"caption":"sunset","taken_at":"2019-08-12T10:00:00+00:00","path":"photos/201908/p.jpg"}]}`), 0644)
+	afero.WriteFile(fs, "/tmp/user-a/data/searches.json", []byte(`[{"search_click":"bob","time":"2019-08-12T10:00:00+00:00"}]`), 0644)
+	afero.WriteFile(fs, "/tmp/user-a/data/messages.json", []byte(`[{"participants":["me"],"conversation":[{"sender":"me","text":"hi"}]}]`), 0644)
+
+	cases := map[string]struct {
+		location string
+		valid    bool
+	}{
+		MediaPattern.Name:    {"/tmp/user-a/data/media.json", true},
+		SearchesPattern.Name: {"/tmp/user-a/data/searches.json", true},
+		// created_at of a message is required
+		MessagesPattern.Name: {"/tmp/user-a/data/messages.json", false},
+	}
+	for _, p := range []facebook.Pattern{MediaPattern, SearchesPattern, MessagesPattern} {
+		c, ok := cases[p.Name]
+		if !ok {
+			continue
+		}
+		filenames, err := p.SelectFiles(fs, c.location)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{c.location}, filenames)
+
+		data, err := afero.ReadFile(fs, c.location)
+		assert.NoError(t, err)
+		assert.Equal(t, c.valid, p.Validate(data) == nil, p.Name)
+	}
+
+	filenames, err := LikesPattern.SelectFiles(fs, "/tmp/user-a/data/likes.json")
+	assert.NoError(t, err)
+	assert.Empty(t, filenames)
+}
+
+func TestParseTime(t *testing.T) {
+	assert.Equal(t, 1565604000, timestamp("2019-08-12T10:00:00+00:00"))
+	assert.Equal(t, 1565604000, timestamp("2019-08-12T10:00:00.123456+00:00"))
+	assert.Equal(t, 1565604000, timestamp("2019-08-12T10:00:00"))
+	assert.Equal(t, 0, timestamp("yesterday"))
+}
@@ -0,0 +1,75 @@
+package instagram
+
+import (
+	"github.com/xeipuuv/gojsonschema"
+
+	"github.com/bitmark-inc/datapod/data-parser/schema/facebook"
+)
+
+type RawProfile struct {
+	Biography      string `json:"biography"`
+	DateJoined     string `json:"date_joined" jsonschema:"required"`
+	Email          string `json:"email"`
+	Gender         string `json:"gender"`
+	Name           string `json:"name"`
+	PhoneNumber    string `json:"phone_number"`
+	PrivateAccount bool   `json:"private_account"`
+	ProfilePicURL  string `json:"profile_pic_url"`
+	Username       string `json:"username" jsonschema:"required"`
+	Website        string `json:"website"`
+	DateOfBirth    string `json:"date_of_birth"`
+}
+
+func ProfileSchemaLoader() *gojsonschema.Schema {
+	return facebook.SchemaLoaderOf(&RawProfile{})
+}
+
+// ORM returns the profile followed by its email and contacts, in the profile tables of Facebook.
+func (r RawProfile) ORM(ids facebook.IDGenerator, owner, archiveID string) []interface{} {
+	profile := facebook.ProfileORM{
+		ProfileID:             ids.NextID(),
+		FullName:              r.Name,
+		Username:              r.Username,
+		ProfileURI:            "https://www.instagram.com/" + r.Username,
+		Gender:                r.Gender,
+		AboutMe:               r.Biography,
+		RegistrationTimestamp: timestamp(r.DateJoined),
+		DataOwnerID:           owner,
+		ArchiveID:             archiveID,
+	}
+	if birthday := parseTime(r.DateOfBirth + "T00:00:00"); !birthday.IsZero() {
+		profile.BirthYear = birthday.Year()
+		profile.BirthMonth = int(birthday.Month())
+		profile.BirthDay = birthday.Day()
+	}
+	result := []interface{}{profile}
+
+	if r.Email != "" {
+		result = append(result, facebook.ProfileEmailORM{
+			ProfileID:   profile.ProfileID,
+			Email:       r.Email,
+			Type:        facebook.ProfileEmailCurrent,
+			DataOwnerID: owner,
+			ArchiveID:   archiveID,
+		})
+	}
+	if r.PhoneNumber != "" {
+		result = append(result, facebook.ProfileContactORM{
+			ProfileID:   profile.ProfileID,
+			Type:        "phone",
+			Value:       r.PhoneNumber,
+			DataOwnerID: owner,
+			ArchiveID:   archiveID,
+		})
+	}
+	if r.Website != "" {
+		result = append(result, facebook.ProfileContactORM{
+			ProfileID:   profile.ProfileID,
+			Type:        "website",
+			Value:       r.Website,
+			DataOwnerID: owner,
+			ArchiveID:   archiveID,
+		})
+	}
+	return result
+}
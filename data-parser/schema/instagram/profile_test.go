@@ -0,0 +1,42 @@
+package instagram
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/bitmark-inc/datapod/data-parser/schema/facebook"
+)
+
+func TestProfileORM(t *testing.T) {
+	data := []byte(`{"biography":"Gopher","date_joined":"2015-01-02T03:04:05+00:00","email":"me@example.com","gender":"female","name":"Me","phone_number":"+886 900","private_account":true,"username":"me_123","website":"https://example.com","date_of_birth":"1990-01-02"}`)
+	assert.NoError(t, ProfilePattern.Validate(data))
+	// the username is required
+	assert.Error(t, ProfilePattern.Validate([]byte(`{"date_joined":"2015-01-02T03:04:05+00:00"}`)))
+
+	var raw RawProfile
+	assert.NoError(t, json.Unmarshal(data, &raw))
+	rows := raw.ORM(&sequence{}, "owner", "archive")
+	assert.Len(t, rows, 4)
+
+	profile := rows[0].(facebook.ProfileORM)
+	assert.Equal(t, int64(1), profile.ProfileID)
+	assert.Equal(t, "Me", profile.FullName)
+	assert.Equal(t, "me_123", profile.Username)
+	assert.Equal(t, "https://www.instagram.com/me_123", profile.ProfileURI)
+	assert.Equal(t, "Gopher", profile.AboutMe)
+	assert.Equal(t, 1420167845, profile.RegistrationTimestamp)
+	assert.Equal(t, 1990, profile.BirthYear)
+	assert.Equal(t, 1, profile.BirthMonth)
+	assert.Equal(t, 2, profile.BirthDay)
+
+	assert.Equal(t, facebook.ProfileEmailORM{ProfileID: 1, Email: "me@example.com", Type: facebook.ProfileEmailCurrent, DataOwnerID: "owner", ArchiveID: "archive"}, rows[1])
+	assert.Equal(t, facebook.ProfileContactORM{ProfileID: 1, Type: "phone", Value: "+886 900", DataOwnerID: "owner", ArchiveID: "archive"}, rows[2])
+	assert.Equal(t, facebook.ProfileContactORM{ProfileID: 1, Type: "website", Value: "https://example.com", DataOwnerID: "owner", ArchiveID: "archive"}, rows[3])
+
+	// without the optional fields
+	rows = RawProfile{DateJoined: "2015-01-02T03:04:05", Username: "me_123"}.ORM(&sequence{}, "owner", "archive")
+	assert.Len(t, rows, 1)
+	assert.Equal(t, 0, rows[0].(facebook.ProfileORM).BirthYear)
+}
@@ -0,0 +1,104 @@
+package instagram
+
+import (
+	"github.com/bitmark-inc/datapod/data-parser/schema/facebook"
+)
+
+// Handlers of an Instagram archive, registered in the order that they run.
+var Handlers = &facebook.Registry{}
+
+func init() {
+	Handlers.Register(&facebook.Category{
+		Files:  ProfilePattern,
+		Tables: []interface{}{facebook.ProfileORM{}, facebook.ProfileEmailORM{}, facebook.ProfileContactORM{}},
+		NewRaw: func() interface{} { return &RawProfile{} },
+		TransformFunc: func(ctx *facebook.ParseContext, file string, v interface{}) ([]interface{}, error) {
+			return v.(*RawProfile).ORM(ctx.IDs, ctx.DataOwner, ctx.ArchiveID), nil
+		},
+	})
+	Handlers.Register(&facebook.Category{
+		Files:  ConnectionsPattern,
+		Tables: []interface{}{facebook.FriendORM{}, facebook.FollowORM{}},
+		NewRaw: func() interface{} { return &RawConnections{} },
+		TransformFunc: func(ctx *facebook.ParseContext, file string, v interface{}) ([]interface{}, error) {
+			rawConnections := v.(*RawConnections)
+			return append(rawConnections.FriendsORM(ctx.IDs, ctx.DataOwner, ctx.ArchiveID), rawConnections.FollowsORM(ctx.DataOwner, ctx.ArchiveID)...), nil
+		},
+	})
+	Handlers.Register(&facebook.Category{
+		Files:  MediaPattern,
+		Tables: []interface{}{facebook.StoryORM{}, facebook.Post{}, facebook.PostMedia{}, facebook.Place{}},
+		NewRaw: func() interface{} { return &RawMedia{} },
+		TransformFunc: func(ctx *facebook.ParseContext, file string, v interface{}) ([]interface{}, error) {
+			rows, posts := v.(*RawMedia).ORM(ctx.IDs, ctx.DataOwner, ctx.ArchiveID)
+			for _, p := range posts {
+				for _, place := range p.Places {
+					ctx.Places.Add(place)
+				}
+				rows = append(rows, p)
+			}
+			return rows, nil
+		},
+		PersistFunc: persistMedia,
+	})
+	Handlers.Register(&facebook.Category{
+		Files:  CommentsPattern,
+		Tables: []interface{}{facebook.CommentORM{}},
+		NewRaw: func() interface{} { return &RawComments{} },
+		TransformFunc: func(ctx *facebook.ParseContext, file string, v interface{}) ([]interface{}, error) {
+			return v.(*RawComments).ORM(ctx.IDs, ctx.DataOwner, ctx.ArchiveID), nil
+		},
+	})
+	Handlers.Register(&facebook.Category{
+		Files:  LikesPattern,
+		Tables: []interface{}{facebook.ReactionORM{}},
+		NewRaw: func() interface{} { return &RawLikes{} },
+		TransformFunc: func(ctx *facebook.ParseContext, file string, v interface{}) ([]interface{}, error) {
+			return v.(*RawLikes).ORM(ctx.IDs, ctx.DataOwner, ctx.ArchiveID), nil
+		},
+	})
+	Handlers.Register(&facebook.Category{
+		Files:  MessagesPattern,
+		Tables: []interface{}{facebook.ConversationORM{}, facebook.ParticipantORM{}, facebook.MessageORM{}, facebook.MessageAttachmentORM{}, facebook.MessageReactionORM{}},
+		NewRaw: func() interface{} { return &[]*Conversation{} },
+		TransformFunc: func(ctx *facebook.ParseContext, file string, v interface{}) ([]interface{}, error) {
+			rawConversations := RawConversations{Items: *v.(*[]*Conversation)}
+			return rawConversations.ORM(ctx.IDs, ctx.DataOwner, ctx.ArchiveID), nil
+		},
+	})
+	Handlers.Register(&facebook.Category{
+		Files:  SearchesPattern,
+		Tables: []interface{}{facebook.SearchORM{}},
+		NewRaw: func() interface{} { return &[]*Search{} },
+		TransformFunc: func(ctx *facebook.ParseContext, file string, v interface{}) ([]interface{}, error) {
+			rawSearches := RawSearches{Items: *v.(*[]*Search)}
+			return rawSearches.ORM(ctx.IDs, ctx.DataOwner, ctx.ArchiveID), nil
+		},
+	})
+	Handlers.Register(&facebook.Category{Files: PhotosPattern, Media: true})
+	Handlers.Register(&facebook.Category{Files: VideosPattern, Media: true})
+	Handlers.Register(&facebook.Category{Files: StoriesPattern, Media: true})
+	Handlers.Register(&facebook.Category{Files: DirectPattern, Media: true})
+}
+
+// persistMedia inserts the stories in one go, while posts are created along with their media and places.
+func persistMedia(ctx *facebook.ParseContext, rows []interface{}) error {
+	stories := make([]interface{}, 0)
+	for _, row := range rows {
+		if _, ok := row.(facebook.Post); !ok {
+			stories = append(stories, row)
+		}
+	}
+	if err := ctx.Sink.BulkInsert(stories); err != nil {
+		return err
+	}
+
+	for _, row := range rows {
+		if p, ok := row.(facebook.Post); ok {
+			if err := ctx.Sink.Create(&p); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
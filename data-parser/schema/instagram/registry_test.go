@@ -0,0 +1,73 @@
+package instagram
+
+import (
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/bitmark-inc/datapod/data-parser/schema/facebook"
+)
+
+type sequence struct {
+	id int64
+}
+
+func (s *sequence) NextID() int64 {
+	s.id++
+	return s.id
+}
+
+type memorySink struct {
+	rows    []interface{}
+	created []interface{}
+}
+
+func (s *memorySink) BulkInsert(rows []interface{}) error {
+	s.rows = append(s.rows, rows...)
+	return nil
+}
+
+func (s *memorySink) Create(row interface{}) error {
+	s.created = append(s.created, row)
+	return nil
+}
+
+func (s *memorySink) FriendIDs(dataOwner, archiveID string) (map[string]int, error) {
+	return map[string]int{}, nil
+}
+
+func TestMediaHandler(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	afero.WriteFile(fs, "/tmp/user-a/media.json", []byte(`{
+		"photos":[{"caption":"sunset","taken_at":"2019-08-12T10:00:00+00:00","location":"Taipei 101","path":"photos/201908/p.jpg"}],
+		"stories":[{"caption":"hi","taken_at":"2019-08-13T10:00:00+00:00","path":"stories/201908/s.mp4"}]
+	}`), 0644)
+
+	handlers, err := Handlers.Handlers(MediaPattern.Name)
+	assert.NoError(t, err)
+	h := handlers[0]
+
+	data, err := afero.ReadFile(fs, "/tmp/user-a/media.json")
+	assert.NoError(t, err)
+	v, err := h.Decode(data)
+	assert.NoError(t, err)
+
+	sink := &memorySink{}
+	ctx := facebook.NewParseContext(&sequence{}, "owner", "archive", sink, nil, "")
+	rows, err := h.Transform(ctx, "/tmp/user-a/media.json", v)
+	assert.NoError(t, err)
+	assert.NoError(t, h.Persist(ctx, rows))
+
+	// stories are inserted in one go, while posts are created with their media and places
+	assert.Len(t, sink.rows, 1)
+	assert.Equal(t, "hi", sink.rows[0].(facebook.StoryORM).Title)
+	assert.Len(t, sink.created, 1)
+	post := sink.created[0].(*facebook.Post)
+	assert.Equal(t, "sunset", post.Post)
+	assert.Len(t, post.MediaItems, 1)
+	assert.Len(t, post.Places, 1)
+
+	// the place of the post is known to the later handlers
+	assert.False(t, ctx.Places.Add(post.Places[0]))
+}
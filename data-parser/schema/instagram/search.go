@@ -0,0 +1,38 @@
+package instagram
+
+import (
+	"github.com/xeipuuv/gojsonschema"
+
+	"github.com/bitmark-inc/datapod/data-parser/schema/facebook"
+)
+
+type RawSearches struct {
+	Items []*Search
+}
+
+type Search struct {
+	SearchClick string `json:"search_click" jsonschema:"required"`
+	Time        string `json:"time" jsonschema:"required"`
+	Type        string `json:"type"`
+}
+
+func SearchArraySchemaLoader() *gojsonschema.Schema {
+	return facebook.ArraySchemaLoaderOf(&Search{})
+}
+
+func (r RawSearches) ORM(ids facebook.IDGenerator, owner, archiveID string) []interface{} {
+	result := make([]interface{}, 0)
+	for _, s := range r.Items {
+		t := parseTime(s.Time)
+		result = append(result, facebook.SearchORM{
+			SearchID:    ids.NextID(),
+			Query:       s.SearchClick,
+			Timestamp:   timestamp(s.Time),
+			Date:        facebook.DateOfTime(t),
+			Weekday:     facebook.WeekdayOfTime(t),
+			DataOwnerID: owner,
+			ArchiveID:   archiveID,
+		})
+	}
+	return result
+}
@@ -0,0 +1,28 @@
+package instagram
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/bitmark-inc/datapod/data-parser/schema/facebook"
+)
+
+func TestSearchesORM(t *testing.T) {
+	data := []byte(`[{"search_click":"alice","time":"2019-08-12T10:00:00+00:00","type":"user"},{"search_click":"#gopher","time":"yesterday"}]`)
+	assert.NoError(t, SearchesPattern.Validate(data))
+	// the time of a search is required
+	assert.Error(t, SearchesPattern.Validate([]byte(`[{"search_click":"alice"}]`)))
+
+	var items []*Search
+	assert.NoError(t, json.Unmarshal(data, &items))
+	searches := RawSearches{Items: items}.ORM(&sequence{}, "owner", "archive")
+
+	assert.Len(t, searches, 2)
+	assert.Equal(t, "alice", searches[0].(facebook.SearchORM).Query)
+	assert.Equal(t, 1565604000, searches[0].(facebook.SearchORM).Timestamp)
+	// an invalid time is kept as 0
+	assert.Equal(t, "#gopher", searches[1].(facebook.SearchORM).Query)
+	assert.Equal(t, 0, searches[1].(facebook.SearchORM).Timestamp)
+}
@@ -0,0 +1,173 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+
+	"github.com/jinzhu/gorm"
+	"github.com/t-tiger/gorm-bulk-insert"
+
+	"github.com/bitmark-inc/datapod/data-parser/schema/facebook"
+)
+
+// recordSink receives the ORM rows produced while parsing an archive.
+type recordSink interface {
+	facebook.Sink
+	// DeleteArchive removes the rows of the models previously parsed from an archive.
+	// The models are in the order that their rows can be deleted.
+	DeleteArchive(dataOwner, archiveID string, models []interface{}) error
+	// DeletePatterns removes the rows of the models recorded for the patterns of an archive,
+	// the models have a pattern column.
+	DeletePatterns(dataOwner, archiveID string, patterns []string, models []interface{}) error
+}
+
+// countingSink counts the rows sent to the underlying sink.
+type countingSink struct {
+	recordSink
+	rows int
+}
+
+func (s *countingSink) BulkInsert(rows []interface{}) error {
+	if err := s.recordSink.BulkInsert(rows); err != nil {
+		return err
+	}
+	s.rows += len(rows)
+	return nil
+}
+
+func (s *countingSink) Create(row interface{}) error {
+	if err := s.recordSink.Create(row); err != nil {
+		return err
+	}
+	s.rows++
+	return nil
+}
+
+type gormSink struct {
+	db *gorm.DB
+}
+
+func (s *gormSink) BulkInsert(rows []interface{}) error {
+	return gormbulk.BulkInsert(s.db, rows, 1000)
+}
+
+func (s *gormSink) Create(row interface{}) error {
+	return s.db.Create(row).Error
+}
+
+func (s *gormSink) FriendIDs(dataOwner, archiveID string) (map[string]int, error) {
+	friends := make([]facebook.FriendORM, 0)
+	if err := s.db.Where("data_owner_id = ? AND archive_id = ?", dataOwner, archiveID).Find(&friends).Error; err != nil {
+		return nil, err
+	}
+
+	friendIDs := make(map[string]int)
+	for _, f := range friends {
+		friendIDs[f.FriendName] = f.PKID
+	}
+	return friendIDs, nil
+}
+
+func (s *gormSink) DeleteArchive(dataOwner, archiveID string, models []interface{}) error {
+	for _, m := range models {
+		if err := s.db.Where("data_owner_id = ? AND archive_id = ?", dataOwner, archiveID).Delete(m).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *gormSink) DeletePatterns(dataOwner, archiveID string, patterns []string, models []interface{}) error {
+	for _, m := range models {
+		if err := s.db.Where("data_owner_id = ? AND archive_id = ? AND pattern IN (?)", dataOwner, archiveID, patterns).Delete(m).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type tabler interface {
+	TableName() string
+}
+
+// jsonlSink writes rows as JSON Lines, one file per table, into a directory.
+type jsonlSink struct {
+	dir       string
+	files     map[string]*os.File
+	friendIDs map[string]int
+}
+
+func newJSONLSink(dir string) (*jsonlSink, error) {
+	if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+		return nil, err
+	}
+	return &jsonlSink{
+		dir:       dir,
+		files:     make(map[string]*os.File),
+		friendIDs: make(map[string]int),
+	}, nil
+}
+
+func (s *jsonlSink) BulkInsert(rows []interface{}) error {
+	for _, row := range rows {
+		if err := s.Create(row); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *jsonlSink) Create(row interface{}) error {
+	// friends get their primary keys from the database sequence,
+	// so mimic it for resolving tags
+	if f, ok := row.(facebook.FriendORM); ok {
+		f.PKID = len(s.friendIDs) + 1
+		s.friendIDs[f.FriendName] = f.PKID
+		row = f
+	}
+
+	t, ok := reflect.Indirect(reflect.ValueOf(row)).Interface().(tabler)
+	if !ok {
+		return fmt.Errorf("unknown table for %T", row)
+	}
+
+	f, ok := s.files[t.TableName()]
+	if !ok {
+		var err error
+		f, err = os.Create(filepath.Join(s.dir, t.TableName()+".jsonl"))
+		if err != nil {
+			return err
+		}
+		s.files[t.TableName()] = f
+	}
+
+	return json.NewEncoder(f).Encode(row)
+}
+
+func (s *jsonlSink) FriendIDs(dataOwner, archiveID string) (map[string]int, error) {
+	return s.friendIDs, nil
+}
+
+// DeleteArchive does nothing since the output files are always written from scratch.
+func (s *jsonlSink) DeleteArchive(dataOwner, archiveID string, models []interface{}) error {
+	return nil
+}
+
+// DeletePatterns does nothing for the same reason as DeleteArchive.
+func (s *jsonlSink) DeletePatterns(dataOwner, archiveID string, patterns []string, models []interface{}) error {
+	return nil
+}
+
+func (s *jsonlSink) Close() error {
+	var firstErr error
+	for _, f := range s.files {
+		if err := f.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	s.files = make(map[string]*os.File)
+	return firstErr
+}
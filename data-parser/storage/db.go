@@ -1,10 +1,20 @@
 package storage
 
 import (
+	"errors"
+	"time"
+
 	"github.com/jinzhu/gorm"
 	_ "github.com/jinzhu/gorm/dialects/postgres"
 )
 
+// ErrLeaseLost is returned when a task is no longer claimed by the parser updating it,
+// for example its lease has expired and the task has been reaped or claimed by another parser.
+var ErrLeaseLost = errors.New("task lease lost")
+
+// ErrTaskNotFound is returned when claiming a task that doesn't exist.
+var ErrTaskNotFound = errors.New("task not found")
+
 func NewPostgresORMDB(dbURI string) *gorm.DB {
 	db, err := gorm.Open("postgres", dbURI)
 	if err != nil {
@@ -13,50 +23,241 @@ func NewPostgresORMDB(dbURI string) *gorm.DB {
 	return db
 }
 
-func GetNextRunningTask(db *gorm.DB) (*Task, error) {
-	var task Task
+// GetNextRunningTask claims the oldest pending task of a data owner who has no running task
+// and leases it for the given duration. It is safe to be called concurrently by multiple parsers.
+func GetNextRunningTask(db *gorm.DB, lease time.Duration) (*Task, error) {
+	// the oldest pending task of each data owner, the data owners being claimed
+	// by other parsers are skipped so that they don't hold up the others
+	var candidates []Task
+	err := db.Raw(`
+		SELECT id, data_owner_id, archive_id FROM (
+			SELECT DISTINCT ON (data_owner_id) id, data_owner_id, archive_id, created_at FROM tasks_task t
+			WHERE status = ? AND NOT EXISTS (
+				SELECT 1 FROM tasks_task r WHERE r.data_owner_id = t.data_owner_id AND r.status = ?
+			)
+			ORDER BY data_owner_id, created_at ASC
+		) oldest
+		ORDER BY created_at ASC`, TaskStatusPending, TaskStatusRunning).Scan(&candidates).Error
+	if err != nil {
+		return nil, err
+	}
 
+	for i := range candidates {
+		claimed, err := claimPendingTask(db, &candidates[i], lease)
+		if err != nil {
+			return nil, err
+		}
+		if claimed {
+			return &candidates[i], nil
+		}
+	}
+	return nil, nil
+}
+
+// claimPendingTask claims the task if it is still pending and not locked by another parser.
+func claimPendingTask(db *gorm.DB, task *Task, lease time.Duration) (bool, error) {
 	dbTx := db.Begin()
-	dataOwnerWithRunningTasks := make([]string, 0)
+	if err := dbTx.Error; err != nil {
+		return false, err
+	}
+	defer dbTx.RollbackUnlessCommitted()
+
+	var locked Task
+	err := dbTx.Raw(`
+		SELECT id FROM tasks_task
+		WHERE id = ? AND status = ?
+		FOR UPDATE SKIP LOCKED`, task.ID, TaskStatusPending).Scan(&locked).Error
+	if gorm.IsRecordNotFoundError(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	claimed, err := claimTask(dbTx, task, lease)
+	if err != nil || !claimed {
+		return false, err
+	}
+
+	if err := dbTx.Commit().Error; err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// claimTask marks the task running if its data owner has no running task.
+func claimTask(dbTx *gorm.DB, task *Task, lease time.Duration) (bool, error) {
+	// Another parser may be claiming a different task of the same data owner.
+	// Serialize the claims of a data owner and check for running tasks once the lock
+	// is held, since a claim committed meanwhile is invisible to the earlier queries.
+	var locked bool
+	row := dbTx.Raw("SELECT pg_try_advisory_xact_lock(hashtext(?))", task.DataOwnerID).Row()
+	if err := row.Scan(&locked); err != nil {
+		return false, err
+	}
+	if !locked {
+		return false, nil
+	}
+
+	var running int
 	err := dbTx.
 		Table("tasks_task").
-		Select("data_owner_id").
-		Where("status = ?", TaskStatusRunning).
-		Group("data_owner_id").Having("COUNT(1) > ?", 0).
-		Pluck("data_owner_id", &dataOwnerWithRunningTasks).Error
+		Where("data_owner_id = ? AND status = ?", task.DataOwnerID, TaskStatusRunning).
+		Count(&running).Error
 	if err != nil {
-		return nil, err
+		return false, err
+	}
+	if running > 0 {
+		return false, nil
 	}
 
-	query := dbTx.
-		Preload("Archive").
-		Table("tasks_task").
-		Select("DISTINCT ON (data_owner_id) id, data_owner_id, archive_id").
-		Where("status = ?", TaskStatusPending).
-		Order("data_owner_id, created_at ASC")
-	if len(dataOwnerWithRunningTasks) > 0 {
-		query = query.Not("data_owner_id in (?)", dataOwnerWithRunningTasks)
+	err = dbTx.Model(task).UpdateColumns(map[string]interface{}{
+		"status":           TaskStatusRunning,
+		"lease_expires_at": leaseExpiry(lease),
+		"started_at":       gorm.Expr("now()"),
+		"finished_at":      nil,
+		"failed_pattern":   "",
+		"progress":         TaskProgress{},
+	}).Error
+	if err != nil {
+		return false, err
+	}
+
+	if err := dbTx.Preload("Archive").First(task, "id = ?", task.ID).Error; err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// ClaimTask claims the task of the id if it is pending or failed, a failed task is
+// claimed again for retrying. The task is returned unclaimed if it is in other status
+// or its data owner has a running task. A nil task is returned if the task is being claimed
+// by another parser, and ErrTaskNotFound if it doesn't exist.
+func ClaimTask(db *gorm.DB, id string, lease time.Duration) (*Task, bool, error) {
+	var task Task
+
+	dbTx := db.Begin()
+	if err := dbTx.Error; err != nil {
+		return nil, false, err
 	}
-	err = query.First(&task).Error
+	defer dbTx.RollbackUnlessCommitted()
+
+	err := dbTx.Raw(`
+		SELECT id, data_owner_id, archive_id, status FROM tasks_task
+		WHERE id = ?
+		FOR UPDATE SKIP LOCKED`, id).Scan(&task).Error
 	if gorm.IsRecordNotFoundError(err) {
-		return nil, nil
+		// a task locked by another parser is skipped, but it is still found without the lock
+		var found int
+		if err := dbTx.Table("tasks_task").Where("id = ?", id).Count(&found).Error; err != nil {
+			return nil, false, err
+		}
+		if found == 0 {
+			return nil, false, ErrTaskNotFound
+		}
+		return nil, false, nil
 	}
 	if err != nil {
-		return nil, err
+		return nil, false, err
 	}
 
-	if err := dbTx.Model(&task).UpdateColumn("status", TaskStatusRunning).Error; err != nil {
-		dbTx.Rollback()
-		return nil, err
+	status := TaskStatusType(task.Status)
+	if status != TaskStatusPending && status != TaskStatusFailed {
+		return &task, false, nil
+	}
+
+	claimed, err := claimTask(dbTx, &task, lease)
+	if err != nil || !claimed {
+		return &task, false, err
 	}
 
 	if err := dbTx.Commit().Error; err != nil {
-		return nil, err
+		return nil, false, err
 	}
 
-	return &task, nil
+	return &task, true, nil
+}
+
+// claimed scopes the updates of a task to the claim of the parser running it. The start time of
+// a task is set by each claim, so it tells the claims of a task apart.
+func claimed(db *gorm.DB, task *Task) *gorm.DB {
+	return db.Model(task).Where("status = ? AND started_at = ?", TaskStatusRunning, task.StartedAt)
+}
+
+// leased returns ErrLeaseLost if the update of a task affected no row.
+func leased(result *gorm.DB) error {
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrLeaseLost
+	}
+	return nil
+}
+
+// UpdateTaskStatus releases the lease of a task and records the error and the pattern
+// being parsed when it failed, those of a previous failure are cleared if it succeeded.
+// It returns ErrLeaseLost if the task is no longer claimed.
+func UpdateTaskStatus(db *gorm.DB, task *Task, status TaskStatusType, taskErr error) error {
+	columns := map[string]interface{}{
+		"status":           status,
+		"lease_expires_at": nil,
+		"last_error":       "",
+		"failed_pattern":   "",
+	}
+	if taskErr != nil {
+		columns["last_error"] = taskErr.Error()
+		columns["failed_pattern"] = task.FailedPattern
+	}
+	if status == TaskStatusFinished || status == TaskStatusFailed {
+		columns["finished_at"] = gorm.Expr("now()")
+	}
+	return leased(claimed(db, task).UpdateColumns(columns))
+}
+
+func UpdateTaskProgress(db *gorm.DB, task *Task) error {
+	return leased(claimed(db, task).UpdateColumn("progress", task.Progress))
+}
+
+func leaseExpiry(lease time.Duration) interface{} {
+	return gorm.Expr("now() + ? * interval '1 second'", lease.Seconds())
+}
+
+// RenewTaskLease extends the lease of a running task, it is the heartbeat of the parser running the task.
+// It returns ErrLeaseLost if the task is no longer claimed, in which case the parser should abort it.
+func RenewTaskLease(db *gorm.DB, task *Task, lease time.Duration) error {
+	return leased(claimed(db, task).UpdateColumn("lease_expires_at", leaseExpiry(lease)))
+}
+
+// retryStatus is the status of a task being retried, which is failed once it has been retried maxRetries times.
+func retryStatus(maxRetries int) interface{} {
+	return gorm.Expr("CASE WHEN retry_count + 1 > ? THEN ? ELSE ? END", maxRetries, TaskStatusFailed, TaskStatusPending)
+}
+
+// ReapExpiredTasks moves running tasks whose leases have expired back to pending,
+// or to failed once they have been retried maxRetries times.
+// Running tasks without a lease are claimed by parsers that don't lease tasks, and are left to them.
+func ReapExpiredTasks(db *gorm.DB, maxRetries int) (int64, error) {
+	result := db.Table("tasks_task").
+		Where("status = ? AND lease_expires_at < now()", TaskStatusRunning).
+		UpdateColumns(map[string]interface{}{
+			"status":           retryStatus(maxRetries),
+			"retry_count":      gorm.Expr("retry_count + 1"),
+			"last_error":       "lease expired",
+			"lease_expires_at": nil,
+		})
+	return result.RowsAffected, result.Error
 }
 
-func UpdateTaskStatus(db *gorm.DB, task *Task, status TaskStatusType) error {
-	return db.Model(task).UpdateColumn("status", status).Error
+// RetryTask releases a claimed task which can't be parsed by this parser for now, and moves it back
+// to pending, or to failed once it has been retried maxRetries times as ReapExpiredTasks does.
+// It returns ErrLeaseLost if the task is no longer claimed.
+func RetryTask(db *gorm.DB, task *Task, maxRetries int, taskErr error) error {
+	return leased(claimed(db, task).UpdateColumns(map[string]interface{}{
+		"status":           retryStatus(maxRetries),
+		"retry_count":      gorm.Expr("retry_count + 1"),
+		"last_error":       taskErr.Error(),
+		"lease_expires_at": nil,
+	}))
 }
@@ -1,6 +1,10 @@
 package storage
 
 import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"strings"
 	"time"
 )
 
@@ -33,8 +37,71 @@ type Task struct {
 	Archive     Archive `gorm:"foreignkey:ArchiveID;association_foreignkey:ID"`
 	Status      int
 	CreatedAt   time.Time
+	// a running task whose lease has expired is considered abandoned by its parser
+	LeaseExpiresAt *time.Time
+	RetryCount     int
+	LastError      string
+	FailedPattern  string
+	// Patterns are the comma-separated names of the patterns to parse, all patterns are parsed if it is empty
+	Patterns   string
+	Progress   TaskProgress `sql:"type:jsonb"`
+	StartedAt  *time.Time
+	FinishedAt *time.Time
 }
 
 func (Task) TableName() string {
 	return "tasks_task"
 }
+
+// PatternNames returns the names of the patterns to parse, or nil for all patterns.
+func (t *Task) PatternNames() []string {
+	return SplitPatternNames(t.Patterns)
+}
+
+// SplitPatternNames splits comma-separated pattern names, ignoring the spaces and empty names.
+func SplitPatternNames(s string) []string {
+	var names []string
+	for _, name := range strings.Split(s, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+type PatternProgress struct {
+	Name string `json:"name"`
+	Rows int    `json:"rows"`
+	// Quarantined is the number of invalid records left out
+	Quarantined int        `json:"quarantined"`
+	StartedAt   time.Time  `json:"started_at"`
+	FinishedAt  *time.Time `json:"finished_at,omitempty"`
+}
+
+// TaskProgress lists the patterns of a task in the order they are parsed.
+type TaskProgress []*PatternProgress
+
+func (p TaskProgress) Value() (driver.Value, error) {
+	if p == nil {
+		p = TaskProgress{}
+	}
+	data, err := json.Marshal(p)
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
+
+func (p *TaskProgress) Scan(src interface{}) error {
+	switch data := src.(type) {
+	case nil:
+		*p = nil
+		return nil
+	case []byte:
+		return json.Unmarshal(data, p)
+	case string:
+		return json.Unmarshal([]byte(data), p)
+	default:
+		return fmt.Errorf("cannot scan %T into task progress", src)
+	}
+}
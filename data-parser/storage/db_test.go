@@ -0,0 +1,202 @@
+package storage
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jinzhu/gorm"
+	"github.com/stretchr/testify/assert"
+)
+
+// newTestDB connects to the Postgres at POSTGRES_TEST_URI and creates the tables
+// in testdata/baseline.sql in a new schema, which is dropped by the returned function.
+// The migrations are then applied to the tables.
+func newTestDB(t *testing.T) (*gorm.DB, func()) {
+	uri := os.Getenv("POSTGRES_TEST_URI")
+	if uri == "" {
+		t.Skip("POSTGRES_TEST_URI is not set")
+	}
+
+	admin := NewPostgresORMDB(uri)
+	schema := fmt.Sprintf("data_parser_test_%d", time.Now().UnixNano())
+	assert.NoError(t, admin.Exec("CREATE SCHEMA "+schema).Error)
+
+	separator := "?"
+	if strings.Contains(uri, "?") {
+		separator = "&"
+	}
+	db := NewPostgresORMDB(uri + separator + "search_path=" + schema)
+	cleanup := func() {
+		db.Close()
+		admin.Exec("DROP SCHEMA " + schema + " CASCADE")
+		admin.Close()
+	}
+
+	migrations, err := filepath.Glob("migrations/*.sql")
+	assert.NoError(t, err)
+	for _, file := range append([]string{"testdata/baseline.sql"}, migrations...) {
+		ddl, err := ioutil.ReadFile(file)
+		assert.NoError(t, err)
+		assert.NoError(t, db.Exec(string(ddl)).Error, file)
+	}
+
+	return db, cleanup
+}
+
+func TestGetNextRunningTaskConcurrently(t *testing.T) {
+	db, cleanup := newTestDB(t)
+	defer cleanup()
+
+	const owners = 5
+	const tasksPerOwner = 4
+	now := time.Now()
+	for o := 0; o < owners; o++ {
+		owner := fmt.Sprintf("owner-%d", o)
+		for i := 0; i < tasksPerOwner; i++ {
+			archive := Archive{ID: fmt.Sprintf("%s-archive-%d", owner, i), DataOwnerID: owner, UploadedAt: now}
+			assert.NoError(t, db.Create(&archive).Error)
+			task := Task{
+				ID:          fmt.Sprintf("%s-task-%d", owner, i),
+				DataOwnerID: owner,
+				ArchiveID:   archive.ID,
+				Status:      int(TaskStatusPending),
+				CreatedAt:   now.Add(time.Duration(i) * time.Second),
+			}
+			assert.NoError(t, db.Create(&task).Error)
+		}
+	}
+
+	var mu sync.Mutex
+	claimed := make(map[string]int)
+	running := make(map[string]bool)
+
+	var wg sync.WaitGroup
+	for w := 0; w < 10; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for idle := 0; idle < 20; {
+				task, err := GetNextRunningTask(db, time.Minute)
+				assert.NoError(t, err)
+				if task == nil {
+					idle++
+					time.Sleep(10 * time.Millisecond)
+					continue
+				}
+				idle = 0
+
+				mu.Lock()
+				assert.False(t, running[task.DataOwnerID], "owner %s has two running tasks", task.DataOwnerID)
+				assert.Equal(t, task.ArchiveID, task.Archive.ID)
+				running[task.DataOwnerID] = true
+				claimed[task.ID]++
+				mu.Unlock()
+
+				time.Sleep(5 * time.Millisecond)
+
+				mu.Lock()
+				running[task.DataOwnerID] = false
+				mu.Unlock()
+				assert.NoError(t, UpdateTaskStatus(db, task, TaskStatusFinished, nil))
+			}
+		}()
+	}
+	wg.Wait()
+
+	assert.Len(t, claimed, owners*tasksPerOwner)
+	for id, n := range claimed {
+		assert.Equal(t, 1, n, "task %s is claimed %d times", id, n)
+	}
+}
+
+func TestGetNextRunningTaskSkipsOwnersBeingClaimed(t *testing.T) {
+	db, cleanup := newTestDB(t)
+	defer cleanup()
+
+	now := time.Now()
+	for i, owner := range []string{"owner-a", "owner-b"} {
+		assert.NoError(t, db.Create(&Archive{ID: owner, DataOwnerID: owner, UploadedAt: now}).Error)
+		assert.NoError(t, db.Create(&Task{ID: owner, DataOwnerID: owner, ArchiveID: owner, Status: int(TaskStatusPending), CreatedAt: now.Add(time.Duration(i) * time.Second)}).Error)
+	}
+
+	// another parser is claiming a task of owner-a
+	claiming := db.Begin()
+	defer claiming.Rollback()
+	assert.NoError(t, claiming.Exec("SELECT pg_advisory_xact_lock(hashtext(?))", "owner-a").Error)
+
+	task, err := GetNextRunningTask(db, time.Minute)
+	assert.NoError(t, err)
+	if assert.NotNil(t, task) {
+		assert.Equal(t, "owner-b", task.ID)
+	}
+
+	claiming.Rollback()
+	task, err = GetNextRunningTask(db, time.Minute)
+	assert.NoError(t, err)
+	if assert.NotNil(t, task) {
+		assert.Equal(t, "owner-a", task.ID)
+	}
+}
+
+func TestReapExpiredTasks(t *testing.T) {
+	db, cleanup := newTestDB(t)
+	defer cleanup()
+
+	assert.NoError(t, db.Create(&Archive{ID: "archive", DataOwnerID: "owner", UploadedAt: time.Now()}).Error)
+	assert.NoError(t, db.Create(&Task{ID: "task", DataOwnerID: "owner", ArchiveID: "archive", Status: int(TaskStatusPending)}).Error)
+
+	for i := 0; i < 3; i++ {
+		task, err := GetNextRunningTask(db, time.Millisecond)
+		assert.NoError(t, err)
+		assert.NotNil(t, task)
+
+		// the lease is renewed, so the task is not reaped
+		assert.NoError(t, RenewTaskLease(db, task, time.Minute))
+		n, err := ReapExpiredTasks(db, 1)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(0), n)
+
+		// the parser crashes and the lease expires
+		assert.NoError(t, RenewTaskLease(db, task, -time.Second))
+		n, err = ReapExpiredTasks(db, 1)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), n)
+
+		var reaped Task
+		assert.NoError(t, db.First(&reaped, "id = ?", task.ID).Error)
+		assert.Equal(t, i+1, reaped.RetryCount)
+		assert.Equal(t, "lease expired", reaped.LastError)
+
+		// the parser which has lost the task can't update it anymore
+		assert.Equal(t, ErrLeaseLost, RenewTaskLease(db, task, time.Minute))
+		assert.Equal(t, ErrLeaseLost, UpdateTaskStatus(db, task, TaskStatusFinished, nil))
+		if i == 0 {
+			assert.Equal(t, int(TaskStatusPending), reaped.Status)
+		} else {
+			assert.Equal(t, int(TaskStatusFailed), reaped.Status)
+			break
+		}
+	}
+
+	// a task finishing after it is reaped and retried has no error left
+	task, claimed, err := ClaimTask(db, "task", time.Minute)
+	assert.NoError(t, err)
+	assert.True(t, claimed)
+	assert.NoError(t, UpdateTaskStatus(db, task, TaskStatusFinished, nil))
+	var finished Task
+	assert.NoError(t, db.First(&finished, "id = ?", "task").Error)
+	assert.Equal(t, int(TaskStatusFinished), finished.Status)
+	assert.Equal(t, "", finished.LastError)
+
+	// running tasks without a lease are left to the parsers that don't lease tasks
+	assert.NoError(t, db.Create(&Task{ID: "unleased", DataOwnerID: "other", ArchiveID: "archive", Status: int(TaskStatusRunning)}).Error)
+	n, err := ReapExpiredTasks(db, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), n)
+}
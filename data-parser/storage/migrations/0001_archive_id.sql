@@ -0,0 +1,20 @@
+-- The changes made for the data parser to the tables of the Django app of datapod, whose
+-- migrations must match these files. They are applied in the order of their names, and
+-- every statement can be applied again.
+
+-- rows are deleted by archive before an archive is parsed again
+ALTER TABLE friends_friend ADD COLUMN IF NOT EXISTS archive_id text NOT NULL DEFAULT '';
+ALTER TABLE posts_post ADD COLUMN IF NOT EXISTS archive_id text NOT NULL DEFAULT '';
+ALTER TABLE post_media_postmedia ADD COLUMN IF NOT EXISTS archive_id text NOT NULL DEFAULT '';
+ALTER TABLE places_place ADD COLUMN IF NOT EXISTS archive_id text NOT NULL DEFAULT '';
+ALTER TABLE tags_tag ADD COLUMN IF NOT EXISTS archive_id text NOT NULL DEFAULT '';
+ALTER TABLE comments_comment ADD COLUMN IF NOT EXISTS archive_id text NOT NULL DEFAULT '';
+ALTER TABLE reactions_reaction ADD COLUMN IF NOT EXISTS archive_id text NOT NULL DEFAULT '';
+
+CREATE INDEX IF NOT EXISTS friends_friend_data_owner_id_archive_id ON friends_friend (data_owner_id, archive_id);
+CREATE INDEX IF NOT EXISTS posts_post_data_owner_id_archive_id ON posts_post (data_owner_id, archive_id);
+CREATE INDEX IF NOT EXISTS post_media_postmedia_data_owner_id_archive_id ON post_media_postmedia (data_owner_id, archive_id);
+CREATE INDEX IF NOT EXISTS places_place_data_owner_id_archive_id ON places_place (data_owner_id, archive_id);
+CREATE INDEX IF NOT EXISTS tags_tag_data_owner_id_archive_id ON tags_tag (data_owner_id, archive_id);
+CREATE INDEX IF NOT EXISTS comments_comment_data_owner_id_archive_id ON comments_comment (data_owner_id, archive_id);
+CREATE INDEX IF NOT EXISTS reactions_reaction_data_owner_id_archive_id ON reactions_reaction (data_owner_id, archive_id);
@@ -0,0 +1,8 @@
+-- the ids of the parsed rows are allocated by idgen, which doesn't fit in integer
+ALTER TABLE friends_friend ALTER COLUMN friend_id TYPE bigint;
+ALTER TABLE posts_post ALTER COLUMN post_id TYPE bigint;
+ALTER TABLE post_media_postmedia ALTER COLUMN pm_id TYPE bigint;
+ALTER TABLE places_place ALTER COLUMN pp_id TYPE bigint;
+ALTER TABLE tags_tag ALTER COLUMN tfid TYPE bigint;
+ALTER TABLE comments_comment ALTER COLUMN comments_id TYPE bigint;
+ALTER TABLE reactions_reaction ALTER COLUMN reaction_id TYPE bigint;
@@ -0,0 +1,9 @@
+-- a running task whose lease has expired is reaped back to pending, or to failed
+-- once it has been retried too many times
+ALTER TABLE tasks_task
+	ADD COLUMN IF NOT EXISTS lease_expires_at timestamptz,
+	ADD COLUMN IF NOT EXISTS retry_count integer NOT NULL DEFAULT 0,
+	ADD COLUMN IF NOT EXISTS last_error text NOT NULL DEFAULT '';
+
+-- the reaper looks for running tasks with expired leases
+CREATE INDEX IF NOT EXISTS tasks_task_status_lease_expires_at ON tasks_task (status, lease_expires_at);
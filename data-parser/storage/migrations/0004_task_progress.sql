@@ -0,0 +1,6 @@
+-- the progress of a task, and the pattern being parsed when it failed
+ALTER TABLE tasks_task
+	ADD COLUMN IF NOT EXISTS failed_pattern text NOT NULL DEFAULT '',
+	ADD COLUMN IF NOT EXISTS progress jsonb,
+	ADD COLUMN IF NOT EXISTS started_at timestamptz,
+	ADD COLUMN IF NOT EXISTS finished_at timestamptz;
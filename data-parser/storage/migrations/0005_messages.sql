@@ -0,0 +1,63 @@
+-- the conversations in messages/inbox
+
+CREATE TABLE IF NOT EXISTS messages_conversation (
+	conversation_id bigint PRIMARY KEY,
+	title text NOT NULL,
+	thread_type text NOT NULL,
+	thread_path text NOT NULL,
+	is_still_participant boolean NOT NULL,
+	data_owner_id text NOT NULL,
+	archive_id text NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS messages_participant (
+	id bigserial PRIMARY KEY,
+	conversation_id bigint NOT NULL,
+	name text NOT NULL,
+	data_owner_id text NOT NULL,
+	archive_id text NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS messages_message (
+	message_id bigint PRIMARY KEY,
+	conversation_id bigint NOT NULL,
+	sender_name text NOT NULL,
+	timestamp_ms bigint NOT NULL,
+	date text NOT NULL,
+	weekday integer NOT NULL,
+	type text NOT NULL,
+	content text NOT NULL,
+	sticker_uri text NOT NULL,
+	share_link text NOT NULL,
+	share_text text NOT NULL,
+	call_duration integer NOT NULL,
+	missed boolean NOT NULL,
+	data_owner_id text NOT NULL,
+	archive_id text NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS messages_attachment (
+	attachment_id bigint PRIMARY KEY,
+	message_id bigint NOT NULL,
+	type text NOT NULL,
+	media_uri text NOT NULL,
+	filename_extension text NOT NULL,
+	creation_timestamp integer NOT NULL,
+	data_owner_id text NOT NULL,
+	archive_id text NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS messages_reaction (
+	id bigserial PRIMARY KEY,
+	message_id bigint NOT NULL,
+	reaction text NOT NULL,
+	actor text NOT NULL,
+	data_owner_id text NOT NULL,
+	archive_id text NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS messages_conversation_data_owner_id_archive_id ON messages_conversation (data_owner_id, archive_id);
+CREATE INDEX IF NOT EXISTS messages_participant_data_owner_id_archive_id ON messages_participant (data_owner_id, archive_id);
+CREATE INDEX IF NOT EXISTS messages_message_data_owner_id_archive_id ON messages_message (data_owner_id, archive_id);
+CREATE INDEX IF NOT EXISTS messages_attachment_data_owner_id_archive_id ON messages_attachment (data_owner_id, archive_id);
+CREATE INDEX IF NOT EXISTS messages_reaction_data_owner_id_archive_id ON messages_reaction (data_owner_id, archive_id);
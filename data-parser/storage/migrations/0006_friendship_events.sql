@@ -0,0 +1,16 @@
+-- the friend requests and removed friends in friends
+
+CREATE TABLE IF NOT EXISTS friends_friendshipevent (
+	event_id bigint PRIMARY KEY,
+	type text NOT NULL,
+	friend_name text NOT NULL,
+	-- the friend of the same name, if any
+	friend_pk_id integer,
+	timestamp integer NOT NULL,
+	date text NOT NULL,
+	weekday integer NOT NULL,
+	data_owner_id text NOT NULL,
+	archive_id text NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS friends_friendshipevent_data_owner_id_archive_id ON friends_friendshipevent (data_owner_id, archive_id);
@@ -0,0 +1,116 @@
+-- the profile information and friend peer group of the data owner
+
+CREATE TABLE IF NOT EXISTS profiles_profile (
+	profile_id bigint PRIMARY KEY,
+	full_name text NOT NULL,
+	first_name text NOT NULL,
+	middle_name text NOT NULL,
+	last_name text NOT NULL,
+	username text NOT NULL,
+	profile_uri text NOT NULL,
+	gender text NOT NULL,
+	pronoun text NOT NULL,
+	birth_year integer NOT NULL,
+	birth_month integer NOT NULL,
+	birth_day integer NOT NULL,
+	current_city text NOT NULL,
+	hometown text NOT NULL,
+	relationship_status text NOT NULL,
+	relationship_partner text NOT NULL,
+	relationship_timestamp integer NOT NULL,
+	interested_in text NOT NULL,
+	religious_view text NOT NULL,
+	political_view text NOT NULL,
+	blood_donor_status text NOT NULL,
+	about_me text NOT NULL,
+	favorite_quotes text NOT NULL,
+	registration_timestamp integer NOT NULL,
+	data_owner_id text NOT NULL,
+	archive_id text NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS profiles_email (
+	id bigserial PRIMARY KEY,
+	profile_id bigint NOT NULL,
+	email text NOT NULL,
+	type text NOT NULL,
+	data_owner_id text NOT NULL,
+	archive_id text NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS profiles_history (
+	id bigserial PRIMARY KEY,
+	profile_id bigint NOT NULL,
+	attribute text NOT NULL,
+	value text NOT NULL,
+	type text NOT NULL,
+	timestamp integer NOT NULL,
+	data_owner_id text NOT NULL,
+	archive_id text NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS profiles_familymember (
+	id bigserial PRIMARY KEY,
+	profile_id bigint NOT NULL,
+	name text NOT NULL,
+	relation text NOT NULL,
+	timestamp integer NOT NULL,
+	data_owner_id text NOT NULL,
+	archive_id text NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS profiles_education (
+	id bigserial PRIMARY KEY,
+	profile_id bigint NOT NULL,
+	name text NOT NULL,
+	school_type text NOT NULL,
+	degree text NOT NULL,
+	concentrations text NOT NULL,
+	description text NOT NULL,
+	graduated boolean NOT NULL,
+	start_timestamp integer NOT NULL,
+	end_timestamp integer NOT NULL,
+	timestamp integer NOT NULL,
+	data_owner_id text NOT NULL,
+	archive_id text NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS profiles_workexperience (
+	id bigserial PRIMARY KEY,
+	profile_id bigint NOT NULL,
+	employer text NOT NULL,
+	title text NOT NULL,
+	location text NOT NULL,
+	description text NOT NULL,
+	start_timestamp integer NOT NULL,
+	end_timestamp integer NOT NULL,
+	timestamp integer NOT NULL,
+	data_owner_id text NOT NULL,
+	archive_id text NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS profiles_contact (
+	id bigserial PRIMARY KEY,
+	profile_id bigint NOT NULL,
+	type text NOT NULL,
+	value text NOT NULL,
+	verified boolean NOT NULL,
+	data_owner_id text NOT NULL,
+	archive_id text NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS profiles_friendpeergroup (
+	id bigserial PRIMARY KEY,
+	peer_group text NOT NULL,
+	data_owner_id text NOT NULL,
+	archive_id text NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS profiles_profile_data_owner_id_archive_id ON profiles_profile (data_owner_id, archive_id);
+CREATE INDEX IF NOT EXISTS profiles_email_data_owner_id_archive_id ON profiles_email (data_owner_id, archive_id);
+CREATE INDEX IF NOT EXISTS profiles_history_data_owner_id_archive_id ON profiles_history (data_owner_id, archive_id);
+CREATE INDEX IF NOT EXISTS profiles_familymember_data_owner_id_archive_id ON profiles_familymember (data_owner_id, archive_id);
+CREATE INDEX IF NOT EXISTS profiles_education_data_owner_id_archive_id ON profiles_education (data_owner_id, archive_id);
+CREATE INDEX IF NOT EXISTS profiles_workexperience_data_owner_id_archive_id ON profiles_workexperience (data_owner_id, archive_id);
+CREATE INDEX IF NOT EXISTS profiles_contact_data_owner_id_archive_id ON profiles_contact (data_owner_id, archive_id);
+CREATE INDEX IF NOT EXISTS profiles_friendpeergroup_data_owner_id_archive_id ON profiles_friendpeergroup (data_owner_id, archive_id);
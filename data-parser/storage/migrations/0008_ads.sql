@@ -0,0 +1,44 @@
+-- the files in ads_and_businesses
+
+CREATE TABLE IF NOT EXISTS ads_advertisercontactlist (
+	id bigserial PRIMARY KEY,
+	advertiser_name text NOT NULL,
+	data_owner_id text NOT NULL,
+	archive_id text NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS ads_interaction (
+	interaction_id bigint PRIMARY KEY,
+	title text NOT NULL,
+	action text NOT NULL,
+	timestamp integer NOT NULL,
+	date text NOT NULL,
+	weekday integer NOT NULL,
+	data_owner_id text NOT NULL,
+	archive_id text NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS ads_interest (
+	id bigserial PRIMARY KEY,
+	topic text NOT NULL,
+	data_owner_id text NOT NULL,
+	archive_id text NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS ads_offfacebookactivity (
+	activity_id bigint PRIMARY KEY,
+	app_name text NOT NULL,
+	-- the id of the event given by Facebook
+	event_id bigint NOT NULL,
+	type text NOT NULL,
+	timestamp integer NOT NULL,
+	date text NOT NULL,
+	weekday integer NOT NULL,
+	data_owner_id text NOT NULL,
+	archive_id text NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS ads_advertisercontactlist_data_owner_id_archive_id ON ads_advertisercontactlist (data_owner_id, archive_id);
+CREATE INDEX IF NOT EXISTS ads_interaction_data_owner_id_archive_id ON ads_interaction (data_owner_id, archive_id);
+CREATE INDEX IF NOT EXISTS ads_interest_data_owner_id_archive_id ON ads_interest (data_owner_id, archive_id);
+CREATE INDEX IF NOT EXISTS ads_offfacebookactivity_data_owner_id_archive_id ON ads_offfacebookactivity (data_owner_id, archive_id);
@@ -0,0 +1,6 @@
+-- the places of the location files, which have no posts, are kept along with those of check-ins
+ALTER TABLE places_place
+	ADD COLUMN IF NOT EXISTS timestamp integer NOT NULL DEFAULT 0,
+	ADD COLUMN IF NOT EXISTS date text NOT NULL DEFAULT '',
+	ADD COLUMN IF NOT EXISTS source text NOT NULL DEFAULT '',
+	ALTER COLUMN post_id_id DROP NOT NULL;
@@ -0,0 +1,46 @@
+-- the files in security_and_login_information
+
+CREATE TABLE IF NOT EXISTS security_loginevent (
+	event_id bigint PRIMARY KEY,
+	action text NOT NULL,
+	timestamp integer NOT NULL,
+	date text NOT NULL,
+	weekday integer NOT NULL,
+	site text NOT NULL,
+	ip_address text NOT NULL,
+	user_agent text NOT NULL,
+	datr_cookie text NOT NULL,
+	city text NOT NULL,
+	region text NOT NULL,
+	country text NOT NULL,
+	source text NOT NULL,
+	data_owner_id text NOT NULL,
+	archive_id text NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS security_ipaddress (
+	id bigserial PRIMARY KEY,
+	ip_address text NOT NULL,
+	action text NOT NULL,
+	timestamp integer NOT NULL,
+	data_owner_id text NOT NULL,
+	archive_id text NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS security_session (
+	session_id bigint PRIMARY KEY,
+	created_timestamp integer NOT NULL,
+	updated_timestamp integer NOT NULL,
+	ip_address text NOT NULL,
+	user_agent text NOT NULL,
+	datr_cookie text NOT NULL,
+	device text NOT NULL,
+	location text NOT NULL,
+	app text NOT NULL,
+	data_owner_id text NOT NULL,
+	archive_id text NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS security_loginevent_data_owner_id_archive_id ON security_loginevent (data_owner_id, archive_id);
+CREATE INDEX IF NOT EXISTS security_ipaddress_data_owner_id_archive_id ON security_ipaddress (data_owner_id, archive_id);
+CREATE INDEX IF NOT EXISTS security_session_data_owner_id_archive_id ON security_session (data_owner_id, archive_id);
@@ -0,0 +1,60 @@
+-- the files in groups, pages, events and following_and_followers,
+-- and the groups of comments
+
+CREATE TABLE IF NOT EXISTS groups_group (
+	group_id bigint PRIMARY KEY,
+	name text NOT NULL,
+	-- 0 for the groups only known from comments
+	joined_timestamp integer NOT NULL,
+	data_owner_id text NOT NULL,
+	archive_id text NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS groups_activity (
+	activity_id bigint PRIMARY KEY,
+	group_id bigint,
+	type text NOT NULL,
+	title text NOT NULL,
+	content text NOT NULL,
+	author text NOT NULL,
+	timestamp integer NOT NULL,
+	date text NOT NULL,
+	weekday integer NOT NULL,
+	data_owner_id text NOT NULL,
+	archive_id text NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS pages_pagelike (
+	id bigserial PRIMARY KEY,
+	name text NOT NULL,
+	timestamp integer NOT NULL,
+	data_owner_id text NOT NULL,
+	archive_id text NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS events_eventresponse (
+	event_id bigint PRIMARY KEY,
+	name text NOT NULL,
+	start_timestamp integer NOT NULL,
+	end_timestamp integer NOT NULL,
+	response text NOT NULL,
+	data_owner_id text NOT NULL,
+	archive_id text NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS following_follow (
+	id bigserial PRIMARY KEY,
+	name text NOT NULL,
+	timestamp integer NOT NULL,
+	type text NOT NULL,
+	data_owner_id text NOT NULL,
+	archive_id text NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS groups_group_data_owner_id_archive_id ON groups_group (data_owner_id, archive_id);
+CREATE INDEX IF NOT EXISTS groups_activity_data_owner_id_archive_id ON groups_activity (data_owner_id, archive_id);
+CREATE INDEX IF NOT EXISTS pages_pagelike_data_owner_id_archive_id ON pages_pagelike (data_owner_id, archive_id);
+CREATE INDEX IF NOT EXISTS events_eventresponse_data_owner_id_archive_id ON events_eventresponse (data_owner_id, archive_id);
+CREATE INDEX IF NOT EXISTS following_follow_data_owner_id_archive_id ON following_follow (data_owner_id, archive_id);
+
+ALTER TABLE comments_comment ADD COLUMN IF NOT EXISTS group_id bigint;
@@ -0,0 +1,63 @@
+-- the albums and videos in photos_and_videos
+
+CREATE TABLE IF NOT EXISTS media_album (
+	album_id bigint PRIMARY KEY,
+	name text NOT NULL,
+	description text NOT NULL,
+	last_modified_timestamp integer NOT NULL,
+	cover_photo_uri text NOT NULL,
+	data_owner_id text NOT NULL,
+	archive_id text NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS media_mediaitem (
+	media_id bigint PRIMARY KEY,
+	album_id bigint,
+	type text NOT NULL,
+	media_uri text NOT NULL,
+	filename_extension text NOT NULL,
+	thumbnail_uri text NOT NULL,
+	title text NOT NULL,
+	description text NOT NULL,
+	creation_timestamp integer NOT NULL,
+	data_owner_id text NOT NULL,
+	archive_id text NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS media_metadata (
+	id bigserial PRIMARY KEY,
+	media_id bigint NOT NULL,
+	camera_make text NOT NULL,
+	camera_model text NOT NULL,
+	taken_timestamp integer NOT NULL,
+	modified_timestamp integer NOT NULL,
+	exposure text NOT NULL,
+	focal_length text NOT NULL,
+	f_stop text NOT NULL,
+	iso_speed integer NOT NULL,
+	latitude double precision NOT NULL,
+	longitude double precision NOT NULL,
+	orientation double precision NOT NULL,
+	original_width integer NOT NULL,
+	original_height integer NOT NULL,
+	upload_ip text NOT NULL,
+	upload_timestamp integer NOT NULL,
+	data_owner_id text NOT NULL,
+	archive_id text NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS media_comment (
+	id bigserial PRIMARY KEY,
+	media_id bigint NOT NULL,
+	comment text NOT NULL,
+	author text NOT NULL,
+	"group" text NOT NULL,
+	timestamp bigint NOT NULL,
+	data_owner_id text NOT NULL,
+	archive_id text NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS media_album_data_owner_id_archive_id ON media_album (data_owner_id, archive_id);
+CREATE INDEX IF NOT EXISTS media_mediaitem_data_owner_id_archive_id ON media_mediaitem (data_owner_id, archive_id);
+CREATE INDEX IF NOT EXISTS media_metadata_data_owner_id_archive_id ON media_metadata (data_owner_id, archive_id);
+CREATE INDEX IF NOT EXISTS media_comment_data_owner_id_archive_id ON media_comment (data_owner_id, archive_id);
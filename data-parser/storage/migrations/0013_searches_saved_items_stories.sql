@@ -0,0 +1,58 @@
+-- search history, saved items, stories and the posts of others on the timeline
+
+CREATE TABLE IF NOT EXISTS searches_search (
+	search_id bigint PRIMARY KEY,
+	query text NOT NULL,
+	title text NOT NULL,
+	timestamp integer NOT NULL,
+	date text NOT NULL,
+	weekday integer NOT NULL,
+	data_owner_id text NOT NULL,
+	archive_id text NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS saved_items_saveditem (
+	saved_item_id bigint PRIMARY KEY,
+	title text NOT NULL,
+	name text NOT NULL,
+	source text NOT NULL,
+	url text NOT NULL,
+	timestamp integer NOT NULL,
+	date text NOT NULL,
+	weekday integer NOT NULL,
+	data_owner_id text NOT NULL,
+	archive_id text NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS stories_story (
+	story_id bigint PRIMARY KEY,
+	type text NOT NULL,
+	title text NOT NULL,
+	reaction text NOT NULL,
+	media_uri text NOT NULL,
+	filename_extension text NOT NULL,
+	timestamp integer NOT NULL,
+	data_owner_id text NOT NULL,
+	archive_id text NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS posts_timelinepost (
+	post_id bigint PRIMARY KEY,
+	author text NOT NULL,
+	timestamp integer NOT NULL,
+	date text NOT NULL,
+	weekday integer NOT NULL,
+	title text NOT NULL,
+	post text NOT NULL,
+	external_context_url text NOT NULL,
+	external_context_source text NOT NULL,
+	external_context_name text NOT NULL,
+	media_uri text NOT NULL,
+	data_owner_id text NOT NULL,
+	archive_id text NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS searches_search_data_owner_id_archive_id ON searches_search (data_owner_id, archive_id);
+CREATE INDEX IF NOT EXISTS saved_items_saveditem_data_owner_id_archive_id ON saved_items_saveditem (data_owner_id, archive_id);
+CREATE INDEX IF NOT EXISTS stories_story_data_owner_id_archive_id ON stories_story (data_owner_id, archive_id);
+CREATE INDEX IF NOT EXISTS posts_timelinepost_data_owner_id_archive_id ON posts_timelinepost (data_owner_id, archive_id);
@@ -0,0 +1,2 @@
+-- comma-separated names of the patterns to parse, empty for all patterns
+ALTER TABLE tasks_task ADD COLUMN IF NOT EXISTS patterns text NOT NULL DEFAULT '';
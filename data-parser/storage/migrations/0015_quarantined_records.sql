@@ -0,0 +1,14 @@
+-- the invalid records left out by lenient parsing
+
+CREATE TABLE IF NOT EXISTS parser_quarantinedrecord (
+	id bigserial PRIMARY KEY,
+	pattern text NOT NULL,
+	file text NOT NULL,
+	pointer text NOT NULL,
+	messages text NOT NULL,
+	record text NOT NULL,
+	data_owner_id text NOT NULL,
+	archive_id text NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS parser_quarantinedrecord_data_owner_id_archive_id ON parser_quarantinedrecord (data_owner_id, archive_id);
@@ -0,0 +1,16 @@
+-- how the files of an archive drift from the schemas of their patterns
+
+CREATE TABLE IF NOT EXISTS parser_schemadrift (
+	id bigserial PRIMARY KEY,
+	pattern text NOT NULL,
+	schema_version text NOT NULL,
+	kind text NOT NULL,
+	path text NOT NULL,
+	expected text NOT NULL,
+	found text NOT NULL,
+	count integer NOT NULL,
+	data_owner_id text NOT NULL,
+	archive_id text NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS parser_schemadrift_data_owner_id_archive_id ON parser_schemadrift (data_owner_id, archive_id);
@@ -0,0 +1,269 @@
+package storage
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/endpoints"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3manager"
+)
+
+// ObjectStore keeps the uploaded archives and the media files extracted from them.
+// Keys are slash separated regardless of the underlying storage.
+type ObjectStore interface {
+	Get(key string, w io.WriterAt) error
+	Put(key string, r io.Reader) error
+	List(prefix string) ([]string, error)
+	Delete(key string) error
+}
+
+// RunStore is an ObjectStore keeping track of the objects added under a prefix during a run, so that
+// a failed run can remove them while the objects of the previous runs are kept.
+type RunStore struct {
+	ObjectStore
+	existing map[string]bool
+	added    []string
+}
+
+// NewRunStore lists the objects under the prefix, which are left in place by Rollback.
+func NewRunStore(store ObjectStore, prefix string) (*RunStore, error) {
+	keys, err := store.List(prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	existing := make(map[string]bool)
+	for _, key := range keys {
+		existing[key] = true
+	}
+	return &RunStore{ObjectStore: store, existing: existing}, nil
+}
+
+func (s *RunStore) Put(key string, r io.Reader) error {
+	if err := s.ObjectStore.Put(key, r); err != nil {
+		return err
+	}
+	if !s.existing[key] {
+		s.existing[key] = true
+		s.added = append(s.added, key)
+	}
+	return nil
+}
+
+// Rollback deletes the objects added during the run. Objects put over existing ones are kept,
+// as the rows of the previous runs refer to them.
+func (s *RunStore) Rollback() error {
+	for _, key := range s.added {
+		if err := s.ObjectStore.Delete(key); err != nil {
+			return err
+		}
+		delete(s.existing, key)
+	}
+	s.added = nil
+	return nil
+}
+
+type S3Config struct {
+	Bucket         string
+	Region         string
+	Endpoint       string
+	ForcePathStyle bool // required by S3 compatible services like MinIO
+}
+
+type S3ObjectStore struct {
+	bucket     string
+	svc        *s3.S3
+	uploader   *s3manager.Uploader
+	downloader *s3manager.Downloader
+}
+
+func NewS3ObjectStore(cfg S3Config) (*S3ObjectStore, error) {
+	region := cfg.Region
+	if region == "" {
+		region = endpoints.ApNortheast1RegionID
+	}
+
+	awsConfig := &aws.Config{
+		Region:           aws.String(region),
+		S3ForcePathStyle: aws.Bool(cfg.ForcePathStyle),
+	}
+	if cfg.Endpoint != "" {
+		awsConfig.Endpoint = aws.String(cfg.Endpoint)
+	}
+
+	sess, err := session.NewSession(awsConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	return &S3ObjectStore{
+		bucket:     cfg.Bucket,
+		svc:        s3.New(sess),
+		uploader:   s3manager.NewUploader(sess),
+		downloader: s3manager.NewDownloader(sess),
+	}, nil
+}
+
+func (s *S3ObjectStore) Get(key string, w io.WriterAt) error {
+	input := &s3.GetObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(key),
+	}
+	_, err := s.downloader.Download(w, input)
+	return err
+}
+
+func (s *S3ObjectStore) Put(key string, r io.Reader) error {
+	input := &s3manager.UploadInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(key),
+		Body:   r,
+	}
+	_, err := s.uploader.Upload(input)
+	return err
+}
+
+func (s *S3ObjectStore) List(prefix string) ([]string, error) {
+	keys := make([]string, 0)
+	input := &s3.ListObjectsV2Input{
+		Bucket: aws.String(s.bucket),
+		Prefix: aws.String(prefix),
+	}
+	err := s.svc.ListObjectsV2Pages(input, func(page *s3.ListObjectsV2Output, lastPage bool) bool {
+		for _, obj := range page.Contents {
+			keys = append(keys, aws.StringValue(obj.Key))
+		}
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
+
+func (s *S3ObjectStore) Delete(key string) error {
+	input := &s3.DeleteObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(key),
+	}
+	_, err := s.svc.DeleteObject(input)
+	return err
+}
+
+// LocalObjectStore keeps objects as files under a root directory.
+// It is meant for tests and for running the parser without AWS.
+type LocalObjectStore struct {
+	root string
+}
+
+func NewLocalObjectStore(root string) *LocalObjectStore {
+	return &LocalObjectStore{root: filepath.Clean(root)}
+}
+
+func (s *LocalObjectStore) path(key string) (string, error) {
+	p := filepath.Join(s.root, filepath.FromSlash(key))
+	if !strings.HasPrefix(p, s.root+string(os.PathSeparator)) {
+		return "", fmt.Errorf("invalid object key: %s", key)
+	}
+	return p, nil
+}
+
+func (s *LocalObjectStore) Get(key string, w io.WriterAt) error {
+	p, err := s.path(key)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.Open(p)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	buf := make([]byte, 32*1024)
+	var offset int64
+	for {
+		n, err := f.Read(buf)
+		if n > 0 {
+			if _, err := w.WriteAt(buf[:n], offset); err != nil {
+				return err
+			}
+			offset += int64(n)
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func (s *LocalObjectStore) Put(key string, r io.Reader) error {
+	p, err := s.path(key)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(p), os.ModePerm); err != nil {
+		return err
+	}
+
+	f, err := os.Create(p)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(f, r); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func (s *LocalObjectStore) List(prefix string) ([]string, error) {
+	keys := make([]string, 0)
+	err := filepath.Walk(s.root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		if info.IsDir() {
+			return nil
+		}
+
+		rel, err := filepath.Rel(s.root, path)
+		if err != nil {
+			return err
+		}
+		key := filepath.ToSlash(rel)
+		if strings.HasPrefix(key, prefix) {
+			keys = append(keys, key)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(keys)
+	return keys, nil
+}
+
+func (s *LocalObjectStore) Delete(key string) error {
+	p, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(p); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
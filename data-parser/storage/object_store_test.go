@@ -0,0 +1,59 @@
+package storage
+
+import (
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLocalObjectStore(t *testing.T) {
+	root, err := ioutil.TempDir("", "object-store")
+	assert.NoError(t, err)
+	defer os.RemoveAll(root)
+
+	store := NewLocalObjectStore(root)
+	assert.NoError(t, store.Put("user-a/archive.zip", strings.NewReader("ARCHIVE")))
+	assert.NoError(t, store.Put("user-a/fb_archives/1/photos/a.jpg", strings.NewReader("PHOTO")))
+	assert.NoError(t, store.Put("user-b/archive.zip", strings.NewReader("ARCHIVE")))
+
+	keys, err := store.List("user-a/")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"user-a/archive.zip", "user-a/fb_archives/1/photos/a.jpg"}, keys)
+
+	f, err := ioutil.TempFile(root, "download")
+	assert.NoError(t, err)
+	defer f.Close()
+	assert.NoError(t, store.Get("user-a/archive.zip", f))
+	data, err := ioutil.ReadFile(f.Name())
+	assert.NoError(t, err)
+	assert.Equal(t, "ARCHIVE", string(data))
+
+	assert.NoError(t, store.Delete("user-a/archive.zip"))
+	assert.Error(t, store.Get("user-a/archive.zip", f))
+
+	assert.Error(t, store.Put("../escaped", strings.NewReader("")))
+}
+
+func TestRunStoreRollback(t *testing.T) {
+	root, err := ioutil.TempDir("", "run-store")
+	assert.NoError(t, err)
+	defer os.RemoveAll(root)
+
+	store := NewLocalObjectStore(root)
+	assert.NoError(t, store.Put("user-a/fb_archives/1/photos/a.jpg", strings.NewReader("PHOTO")))
+	assert.NoError(t, store.Put("user-a/fb_archives/2/photos/a.jpg", strings.NewReader("PHOTO")))
+
+	runStore, err := NewRunStore(store, "user-a/fb_archives/1/")
+	assert.NoError(t, err)
+	assert.NoError(t, runStore.Put("user-a/fb_archives/1/photos/a.jpg", strings.NewReader("PHOTO")))
+	assert.NoError(t, runStore.Put("user-a/fb_archives/1/photos/b.jpg", strings.NewReader("PHOTO")))
+	assert.NoError(t, runStore.Rollback())
+
+	// only the object added by the run is deleted
+	keys, err := store.List("")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"user-a/fb_archives/1/photos/a.jpg", "user-a/fb_archives/2/photos/a.jpg"}, keys)
+}
@@ -1,13 +1,16 @@
 package storage
 
 import (
+	"context"
+
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/aws/aws-sdk-go/service/sqs"
+	"github.com/aws/aws-sdk-go/service/sqs/sqsiface"
 )
 
 type SQS struct {
-	svc      *sqs.SQS
+	svc      sqsiface.SQSAPI
 	queueURL string
 }
 
@@ -16,18 +19,36 @@ func NewSQS(sess *session.Session, queueURL string) *SQS {
 	return &SQS{svc, queueURL}
 }
 
-func (s *SQS) Poll() (*sqs.ReceiveMessageOutput, error) {
+// NewSQSWithClient is like NewSQS but takes the client, e.g. a fake one for testing.
+func NewSQSWithClient(svc sqsiface.SQSAPI, queueURL string) *SQS {
+	return &SQS{svc, queueURL}
+}
+
+// Poll waits up to 20 seconds for a message, or until ctx is done.
+func (s *SQS) Poll(ctx context.Context) (*sqs.ReceiveMessageOutput, error) {
 	input := &sqs.ReceiveMessageInput{
 		QueueUrl:        aws.String(s.queueURL),
 		WaitTimeSeconds: aws.Int64(20),
 	}
-	return s.svc.ReceiveMessage(input)
+	return s.svc.ReceiveMessageWithContext(ctx, input)
 }
 
-func (s *SQS) DeleteMessage(m *sqs.Message) {
+func (s *SQS) DeleteMessage(m *sqs.Message) error {
 	input := &sqs.DeleteMessageInput{
 		QueueUrl:      aws.String(s.queueURL),
 		ReceiptHandle: m.ReceiptHandle,
 	}
-	s.svc.DeleteMessage(input)
+	_, err := s.svc.DeleteMessage(input)
+	return err
+}
+
+// ChangeVisibility hides the message from other consumers for the given seconds from now.
+func (s *SQS) ChangeVisibility(m *sqs.Message, seconds int64) error {
+	input := &sqs.ChangeMessageVisibilityInput{
+		QueueUrl:          aws.String(s.queueURL),
+		ReceiptHandle:     m.ReceiptHandle,
+		VisibilityTimeout: aws.Int64(seconds),
+	}
+	_, err := s.svc.ChangeMessageVisibility(input)
+	return err
 }
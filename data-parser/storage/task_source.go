@@ -0,0 +1,188 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/sqs"
+	"github.com/jinzhu/gorm"
+)
+
+// TaskSource hands out the tasks to parse.
+type TaskSource interface {
+	// Claim returns the next task to parse, or nil if there is none for now.
+	// Waiting for a task is stopped once ctx is done.
+	Claim(ctx context.Context) (*Task, error)
+	// Extend keeps a claimed task from being handed out again while it is parsed.
+	Extend(task *Task) error
+	// Complete releases a claimed task with its new status.
+	Complete(task *Task, status TaskStatusType, taskErr error) error
+}
+
+// PostgresTaskSource claims the pending tasks from the tasks table.
+type PostgresTaskSource struct {
+	db    *gorm.DB
+	lease time.Duration
+}
+
+func NewPostgresTaskSource(db *gorm.DB, lease time.Duration) *PostgresTaskSource {
+	return &PostgresTaskSource{db: db, lease: lease}
+}
+
+func (s *PostgresTaskSource) Claim(ctx context.Context) (*Task, error) {
+	return GetNextRunningTask(s.db, s.lease)
+}
+
+func (s *PostgresTaskSource) Extend(task *Task) error {
+	return RenewTaskLease(s.db, task, s.lease)
+}
+
+func (s *PostgresTaskSource) Complete(task *Task, status TaskStatusType, taskErr error) error {
+	return UpdateTaskStatus(s.db, task, status, taskErr)
+}
+
+// the delay before retrying a task whose data owner has a running task
+const minRetryDelay = time.Minute
+
+// TaskMessage is the body of a message in the task queue.
+type TaskMessage struct {
+	TaskID string `json:"task_id"`
+}
+
+// SQSTaskSource claims the tasks announced in a SQS queue. The message of a task
+// is deleted only when the task is finished, so a failed task is retried when the
+// message becomes visible again, until the queue moves it to the dead-letter queue.
+type SQSTaskSource struct {
+	queue      *SQS
+	db         *gorm.DB
+	lease      time.Duration
+	maxRetries int
+
+	mu       sync.Mutex
+	messages map[string]*sqs.Message // by task id
+}
+
+func NewSQSTaskSource(queue *SQS, db *gorm.DB, lease time.Duration, maxRetries int) *SQSTaskSource {
+	return &SQSTaskSource{
+		queue:      queue,
+		db:         db,
+		lease:      lease,
+		maxRetries: maxRetries,
+		messages:   make(map[string]*sqs.Message),
+	}
+}
+
+func (s *SQSTaskSource) Claim(ctx context.Context) (*Task, error) {
+	output, err := s.queue.Poll(ctx)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if len(output.Messages) == 0 {
+		return nil, nil
+	}
+	m := output.Messages[0]
+
+	var body TaskMessage
+	if err := json.Unmarshal([]byte(aws.StringValue(m.Body)), &body); err != nil || body.TaskID == "" {
+		// a malformed message will never be parsed, drop it
+		s.queue.DeleteMessage(m)
+		return nil, fmt.Errorf("invalid task message %s: %s", aws.StringValue(m.MessageId), aws.StringValue(m.Body))
+	}
+
+	task, claimed, err := ClaimTask(s.db, body.TaskID, s.lease)
+	if err == ErrTaskNotFound {
+		// the task is deleted, or the message is not of this database
+		return nil, s.queue.DeleteMessage(m)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if task == nil {
+		// another parser is claiming it, see how it goes later
+		return nil, s.queue.ChangeVisibility(m, int64(minRetryDelay.Seconds()))
+	}
+	if !claimed {
+		switch TaskStatusType(task.Status) {
+		case TaskStatusFinished:
+			// delivered more than once
+			return nil, s.queue.DeleteMessage(m)
+		default:
+			// the data owner has a running task, try it again later
+			return nil, s.queue.ChangeVisibility(m, int64(minRetryDelay.Seconds()))
+		}
+	}
+
+	// the message must be invisible as long as the task is leased
+	if err := s.queue.ChangeVisibility(m, int64(s.lease.Seconds())); err != nil {
+		if retryErr := RetryTask(s.db, task, s.maxRetries, err); retryErr != nil {
+			return nil, fmt.Errorf("%s, and failed to release task %s: %s", err, task.ID, retryErr)
+		}
+		return nil, err
+	}
+
+	s.mu.Lock()
+	s.messages[task.ID] = m
+	s.mu.Unlock()
+
+	return task, nil
+}
+
+func (s *SQSTaskSource) message(task *Task) (*sqs.Message, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	m, ok := s.messages[task.ID]
+	if !ok {
+		return nil, fmt.Errorf("task %s is not claimed from the queue", task.ID)
+	}
+	return m, nil
+}
+
+func (s *SQSTaskSource) Extend(task *Task) error {
+	m, err := s.message(task)
+	if err != nil {
+		return err
+	}
+	if err := s.queue.ChangeVisibility(m, int64(s.lease.Seconds())); err != nil {
+		return err
+	}
+	err = RenewTaskLease(s.db, task, s.lease)
+	if err == ErrLeaseLost {
+		// the task is not completed by this parser, its message shows up again after the visibility timeout
+		s.mu.Lock()
+		delete(s.messages, task.ID)
+		s.mu.Unlock()
+	}
+	return err
+}
+
+func (s *SQSTaskSource) Complete(task *Task, status TaskStatusType, taskErr error) error {
+	m, err := s.message(task)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	delete(s.messages, task.ID)
+	s.mu.Unlock()
+
+	if err := UpdateTaskStatus(s.db, task, status, taskErr); err != nil {
+		return err
+	}
+
+	switch status {
+	case TaskStatusFinished:
+		return s.queue.DeleteMessage(m)
+	case TaskStatusPending:
+		// requeued, hand it out again right away
+		return s.queue.ChangeVisibility(m, 0)
+	default:
+		// failed, the message shows up again after the visibility timeout
+		return nil
+	}
+}
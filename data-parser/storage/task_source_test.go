@@ -0,0 +1,211 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/sqs"
+	"github.com/aws/aws-sdk-go/service/sqs/sqsiface"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeSQS is an in-memory queue which ignores visibility timeouts,
+// hidden messages only show up again after ChangeMessageVisibility to 0.
+type fakeSQS struct {
+	sqsiface.SQSAPI
+
+	mu       sync.Mutex
+	seq      int
+	visible  []*sqs.Message
+	inFlight map[string]*sqs.Message // by receipt handle
+	// visibilityErr is returned by ChangeMessageVisibility if it's set
+	visibilityErr error
+}
+
+func newFakeSQS() *fakeSQS {
+	return &fakeSQS{inFlight: make(map[string]*sqs.Message)}
+}
+
+func (f *fakeSQS) send(body string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.seq++
+	f.visible = append(f.visible, &sqs.Message{MessageId: aws.String(fmt.Sprint(f.seq)), Body: aws.String(body)})
+}
+
+func (f *fakeSQS) ReceiveMessageWithContext(ctx aws.Context, input *sqs.ReceiveMessageInput, opts ...request.Option) (*sqs.ReceiveMessageOutput, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if len(f.visible) == 0 {
+		return &sqs.ReceiveMessageOutput{}, nil
+	}
+	m := f.visible[0]
+	f.visible = f.visible[1:]
+	f.seq++
+	m.ReceiptHandle = aws.String(fmt.Sprint("receipt-", f.seq))
+	f.inFlight[*m.ReceiptHandle] = m
+	return &sqs.ReceiveMessageOutput{Messages: []*sqs.Message{m}}, nil
+}
+
+func (f *fakeSQS) DeleteMessage(input *sqs.DeleteMessageInput) (*sqs.DeleteMessageOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if _, ok := f.inFlight[*input.ReceiptHandle]; !ok {
+		return nil, fmt.Errorf("invalid receipt handle")
+	}
+	delete(f.inFlight, *input.ReceiptHandle)
+	return &sqs.DeleteMessageOutput{}, nil
+}
+
+func (f *fakeSQS) ChangeMessageVisibility(input *sqs.ChangeMessageVisibilityInput) (*sqs.ChangeMessageVisibilityOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.visibilityErr != nil {
+		return nil, f.visibilityErr
+	}
+	m, ok := f.inFlight[*input.ReceiptHandle]
+	if !ok {
+		return nil, fmt.Errorf("invalid receipt handle")
+	}
+	if *input.VisibilityTimeout == 0 {
+		delete(f.inFlight, *input.ReceiptHandle)
+		f.visible = append(f.visible, m)
+	}
+	return &sqs.ChangeMessageVisibilityOutput{}, nil
+}
+
+func (f *fakeSQS) counts() (int, int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.visible), len(f.inFlight)
+}
+
+func TestSQSTaskSource(t *testing.T) {
+	db, cleanup := newTestDB(t)
+	defer cleanup()
+
+	for _, id := range []string{"a", "b"} {
+		assert.NoError(t, db.Create(&Archive{ID: id, DataOwnerID: "owner-" + id, UploadedAt: time.Now()}).Error)
+		assert.NoError(t, db.Create(&Task{ID: id, DataOwnerID: "owner-" + id, ArchiveID: id, Status: int(TaskStatusPending)}).Error)
+	}
+
+	fake := newFakeSQS()
+	source := NewSQSTaskSource(NewSQSWithClient(fake, "queue"), db, time.Minute, 1)
+
+	// malformed messages are dropped
+	fake.send("not json")
+	task, err := source.Claim(context.Background())
+	assert.Error(t, err)
+	assert.Nil(t, task)
+
+	// an empty queue
+	task, err = source.Claim(context.Background())
+	assert.NoError(t, err)
+	assert.Nil(t, task)
+
+	// a finished task deletes its message
+	fake.send(`{"task_id": "a"}`)
+	task, err = source.Claim(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, "a", task.ID)
+	assert.Equal(t, "a", task.Archive.ID)
+	assert.NoError(t, source.Extend(task))
+	assert.NoError(t, source.Complete(task, TaskStatusFinished, nil))
+	visible, inFlight := fake.counts()
+	assert.Equal(t, 0, visible)
+	assert.Equal(t, 0, inFlight)
+
+	// a failed task keeps its message for retrying
+	fake.send(`{"task_id": "b"}`)
+	task, err = source.Claim(context.Background())
+	assert.NoError(t, err)
+	assert.NoError(t, source.Complete(task, TaskStatusFailed, fmt.Errorf("broken archive")))
+	visible, inFlight = fake.counts()
+	assert.Equal(t, 0, visible)
+	assert.Equal(t, 1, inFlight)
+
+	var failed Task
+	assert.NoError(t, db.First(&failed, "id = ?", "b").Error)
+	assert.Equal(t, int(TaskStatusFailed), failed.Status)
+	assert.Equal(t, "broken archive", failed.LastError)
+
+	// a requeued task is handed out again right away
+	fake.send(`{"task_id": "b"}`)
+	task, err = source.Claim(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, "b", task.ID)
+	assert.NoError(t, source.Complete(task, TaskStatusPending, nil))
+	visible, _ = fake.counts()
+	assert.Equal(t, 1, visible)
+	task, err = source.Claim(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, "b", task.ID)
+	assert.NoError(t, source.Complete(task, TaskStatusFinished, nil))
+
+	// a message delivered again after the task is finished is deleted
+	fake.send(`{"task_id": "a"}`)
+	task, err = source.Claim(context.Background())
+	assert.NoError(t, err)
+	assert.Nil(t, task)
+
+	// a message of a task which doesn't exist is deleted
+	fake.send(`{"task_id": "deleted"}`)
+	task, err = source.Claim(context.Background())
+	assert.NoError(t, err)
+	assert.Nil(t, task)
+	visible, inFlight = fake.counts()
+	assert.Equal(t, 0, visible)
+	assert.Equal(t, 0, inFlight)
+
+	// a message of a task being claimed by another parser is deferred
+	assert.NoError(t, db.Create(&Archive{ID: "c", DataOwnerID: "owner-c", UploadedAt: time.Now()}).Error)
+	assert.NoError(t, db.Create(&Task{ID: "c", DataOwnerID: "owner-c", ArchiveID: "c", Status: int(TaskStatusPending)}).Error)
+	claiming := db.Begin()
+	defer claiming.Rollback()
+	assert.NoError(t, claiming.Exec("SELECT id FROM tasks_task WHERE id = ? FOR UPDATE", "c").Error)
+	fake.send(`{"task_id": "c"}`)
+	task, err = source.Claim(context.Background())
+	assert.NoError(t, err)
+	assert.Nil(t, task)
+	visible, inFlight = fake.counts()
+	assert.Equal(t, 0, visible)
+	assert.Equal(t, 1, inFlight)
+	claiming.Rollback()
+
+	// a task whose message can't be hidden is retried, until it has been retried too many times
+	fake.visibilityErr = fmt.Errorf("throttled")
+	for i, status := range []TaskStatusType{TaskStatusPending, TaskStatusFailed} {
+		fake.send(`{"task_id": "c"}`)
+		task, err = source.Claim(context.Background())
+		assert.Error(t, err)
+		assert.Nil(t, task)
+
+		var retried Task
+		assert.NoError(t, db.First(&retried, "id = ?", "c").Error)
+		assert.Equal(t, int(status), retried.Status)
+		assert.Equal(t, i+1, retried.RetryCount)
+		assert.Equal(t, "throttled", retried.LastError)
+	}
+}
+
+func TestSQSTaskSourceStopsPolling(t *testing.T) {
+	fake := newFakeSQS()
+	fake.send(`{"task_id": "a"}`)
+	source := NewSQSTaskSource(NewSQSWithClient(fake, "queue"), nil, time.Minute, 1)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	task, err := source.Claim(ctx)
+	assert.NoError(t, err)
+	assert.Nil(t, task)
+	visible, _ := fake.counts()
+	assert.Equal(t, 1, visible)
+}
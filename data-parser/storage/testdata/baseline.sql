@@ -0,0 +1,92 @@
+-- The tables of the Django app of datapod before the changes in migrations.
+
+CREATE TABLE archives_archive (
+	id text PRIMARY KEY,
+	file text NOT NULL,
+	file_name text NOT NULL,
+	file_size integer NOT NULL,
+	uploaded_at timestamptz NOT NULL,
+	data_owner_id text NOT NULL
+);
+
+CREATE TABLE tasks_task (
+	id text PRIMARY KEY,
+	data_owner_id text NOT NULL,
+	archive_id text NOT NULL REFERENCES archives_archive (id),
+	status integer NOT NULL,
+	created_at timestamptz NOT NULL
+);
+
+CREATE TABLE friends_friend (
+	pk_id serial PRIMARY KEY,
+	friend_id integer NOT NULL,
+	friend_name text NOT NULL,
+	timestamp integer NOT NULL,
+	data_owner_id text NOT NULL
+);
+
+CREATE TABLE posts_post (
+	pk_id serial PRIMARY KEY,
+	post_id integer NOT NULL,
+	timestamp integer NOT NULL,
+	update_timestamp integer NOT NULL,
+	date text NOT NULL,
+	weekday integer NOT NULL,
+	title text NOT NULL,
+	post text NOT NULL,
+	external_context_url text NOT NULL,
+	external_context_source text NOT NULL,
+	external_context_name text NOT NULL,
+	event_name text NOT NULL,
+	event_start_timestamp integer NOT NULL,
+	event_end_timestamp integer NOT NULL,
+	media_attached boolean NOT NULL,
+	sentiment text NOT NULL,
+	data_owner_id text NOT NULL
+);
+
+CREATE TABLE post_media_postmedia (
+	pm_id integer PRIMARY KEY,
+	media_uri text NOT NULL,
+	filename_extension text NOT NULL,
+	data_owner_id text NOT NULL,
+	post_id_id integer NOT NULL
+);
+
+CREATE TABLE places_place (
+	pp_id integer PRIMARY KEY,
+	name text NOT NULL,
+	address text NOT NULL,
+	latitude double precision NOT NULL,
+	longitude double precision NOT NULL,
+	data_owner_id text NOT NULL,
+	post_id_id integer NOT NULL
+);
+
+CREATE TABLE tags_tag (
+	tfid integer PRIMARY KEY,
+	data_owner_id text NOT NULL,
+	post_id_id integer NOT NULL,
+	tags_id integer NOT NULL
+);
+
+CREATE TABLE comments_comment (
+	comments_id integer PRIMARY KEY,
+	timestamp integer NOT NULL,
+	author text NOT NULL,
+	comment text NOT NULL,
+	date text NOT NULL,
+	weekday integer NOT NULL,
+	data_owner_id text NOT NULL
+);
+
+CREATE TABLE reactions_reaction (
+	reaction_id integer PRIMARY KEY,
+	timestamp integer NOT NULL,
+	date text NOT NULL,
+	weekday integer NOT NULL,
+	title text NOT NULL,
+	actor text NOT NULL,
+	reaction text NOT NULL,
+	data_owner_id text NOT NULL
+);
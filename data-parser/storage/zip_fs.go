@@ -0,0 +1,274 @@
+package storage
+
+import (
+	"archive/zip"
+	"errors"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/spf13/afero"
+)
+
+// ZipFs is a read-only afero.Fs over a zip archive, entries are read in place without being extracted.
+// Paths are relative to the root of the archive.
+type ZipFs struct {
+	r     *zip.ReadCloser
+	files map[string]*zip.File
+	// dirs maps each dir, including those only implied by the paths of the files, to its entry names
+	dirs map[string][]string
+}
+
+func OpenZipFs(source string) (*ZipFs, error) {
+	r, err := zip.OpenReader(source)
+	if err != nil {
+		return nil, err
+	}
+
+	fs := &ZipFs{
+		r:     r,
+		files: make(map[string]*zip.File),
+		dirs:  map[string][]string{"": nil},
+	}
+	for _, f := range r.File {
+		name := path.Clean(strings.TrimPrefix(f.Name, "/"))
+		// entries outside of the archive root are never read
+		if name == "." || name == ".." || strings.HasPrefix(name, "../") {
+			continue
+		}
+
+		if f.FileInfo().IsDir() {
+			fs.addDir(name)
+		} else {
+			fs.files[name] = f
+			fs.addEntry(name)
+		}
+	}
+	for _, names := range fs.dirs {
+		sort.Strings(names)
+	}
+	return fs, nil
+}
+
+func (fs *ZipFs) addDir(name string) {
+	if _, ok := fs.dirs[name]; ok {
+		return
+	}
+	fs.dirs[name] = nil
+	fs.addEntry(name)
+}
+
+// addEntry adds the name to its parent dir, which is added to its own parent and so on.
+func (fs *ZipFs) addEntry(name string) {
+	parent := path.Dir(name)
+	if parent == "." {
+		parent = ""
+	}
+	if parent != "" {
+		fs.addDir(parent)
+	}
+	fs.dirs[parent] = append(fs.dirs[parent], path.Base(name))
+}
+
+func (fs *ZipFs) Close() error {
+	return fs.r.Close()
+}
+
+// Names returns the paths of the files in the archive.
+func (fs *ZipFs) Names() []string {
+	names := make([]string, 0, len(fs.files))
+	for name := range fs.files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// normalize turns a path of the fs into the key of its entry.
+func normalize(name string) string {
+	name = path.Clean("/" + filepath.ToSlash(name))
+	return strings.TrimPrefix(name, "/")
+}
+
+func (fs *ZipFs) Name() string {
+	return "ZipFs"
+}
+
+func (fs *ZipFs) Stat(name string) (os.FileInfo, error) {
+	key := normalize(name)
+	if f, ok := fs.files[key]; ok {
+		return f.FileInfo(), nil
+	}
+	if _, ok := fs.dirs[key]; ok {
+		return dirInfo{name: path.Base("/" + key)}, nil
+	}
+	return nil, &os.PathError{Op: "stat", Path: name, Err: os.ErrNotExist}
+}
+
+func (fs *ZipFs) Open(name string) (afero.File, error) {
+	key := normalize(name)
+	if f, ok := fs.files[key]; ok {
+		rc, err := f.Open()
+		if err != nil {
+			return nil, &os.PathError{Op: "open", Path: name, Err: err}
+		}
+		return &zipFile{fs: fs, name: name, info: f.FileInfo(), rc: rc}, nil
+	}
+	if _, ok := fs.dirs[key]; ok {
+		return &zipFile{fs: fs, name: name, info: dirInfo{name: path.Base("/" + key)}, dir: key}, nil
+	}
+	return nil, &os.PathError{Op: "open", Path: name, Err: os.ErrNotExist}
+}
+
+func (fs *ZipFs) OpenFile(name string, flag int, perm os.FileMode) (afero.File, error) {
+	if flag&(os.O_WRONLY|os.O_RDWR|os.O_APPEND|os.O_CREATE|os.O_TRUNC) != 0 {
+		return nil, syscall.EPERM
+	}
+	return fs.Open(name)
+}
+
+func (fs *ZipFs) Create(name string) (afero.File, error) {
+	return nil, syscall.EPERM
+}
+
+func (fs *ZipFs) Mkdir(name string, perm os.FileMode) error {
+	return syscall.EPERM
+}
+
+func (fs *ZipFs) MkdirAll(path string, perm os.FileMode) error {
+	return syscall.EPERM
+}
+
+func (fs *ZipFs) Remove(name string) error {
+	return syscall.EPERM
+}
+
+func (fs *ZipFs) RemoveAll(path string) error {
+	return syscall.EPERM
+}
+
+func (fs *ZipFs) Rename(oldname, newname string) error {
+	return syscall.EPERM
+}
+
+func (fs *ZipFs) Chmod(name string, mode os.FileMode) error {
+	return syscall.EPERM
+}
+
+func (fs *ZipFs) Chtimes(name string, atime time.Time, mtime time.Time) error {
+	return syscall.EPERM
+}
+
+type dirInfo struct {
+	name string
+}
+
+func (d dirInfo) Name() string       { return d.name }
+func (d dirInfo) Size() int64        { return 0 }
+func (d dirInfo) Mode() os.FileMode  { return os.ModeDir | 0555 }
+func (d dirInfo) ModTime() time.Time { return time.Time{} }
+func (d dirInfo) IsDir() bool        { return true }
+func (d dirInfo) Sys() interface{}   { return nil }
+
+var errNotSeekable = errors.New("zip entries are read sequentially")
+
+// zipFile is an opened entry of a ZipFs, files are decompressed as they are read.
+type zipFile struct {
+	fs   *ZipFs
+	name string
+	info os.FileInfo
+	rc   io.ReadCloser
+	// dir is the key of the entry if it is a dir, and offset is the number of its entries read
+	dir    string
+	offset int
+}
+
+func (f *zipFile) Name() string {
+	return f.name
+}
+
+func (f *zipFile) Stat() (os.FileInfo, error) {
+	return f.info, nil
+}
+
+func (f *zipFile) Read(p []byte) (int, error) {
+	if f.rc == nil {
+		return 0, syscall.EISDIR
+	}
+	return f.rc.Read(p)
+}
+
+func (f *zipFile) Close() error {
+	if f.rc == nil {
+		return nil
+	}
+	return f.rc.Close()
+}
+
+func (f *zipFile) Readdir(count int) ([]os.FileInfo, error) {
+	names, err := f.Readdirnames(count)
+	if err != nil {
+		return nil, err
+	}
+
+	infos := make([]os.FileInfo, 0, len(names))
+	for _, name := range names {
+		info, err := f.fs.Stat(path.Join(f.dir, name))
+		if err != nil {
+			return nil, err
+		}
+		infos = append(infos, info)
+	}
+	return infos, nil
+}
+
+func (f *zipFile) Readdirnames(n int) ([]string, error) {
+	if !f.info.IsDir() {
+		return nil, syscall.ENOTDIR
+	}
+
+	names := f.fs.dirs[f.dir][f.offset:]
+	if n > 0 {
+		if len(names) == 0 {
+			return nil, io.EOF
+		}
+		if n < len(names) {
+			names = names[:n]
+		}
+	}
+	f.offset += len(names)
+	return names, nil
+}
+
+func (f *zipFile) ReadAt(p []byte, off int64) (int, error) {
+	return 0, errNotSeekable
+}
+
+func (f *zipFile) Seek(offset int64, whence int) (int64, error) {
+	return 0, errNotSeekable
+}
+
+func (f *zipFile) Write(p []byte) (int, error) {
+	return 0, syscall.EPERM
+}
+
+func (f *zipFile) WriteAt(p []byte, off int64) (int, error) {
+	return 0, syscall.EPERM
+}
+
+func (f *zipFile) WriteString(s string) (int, error) {
+	return 0, syscall.EPERM
+}
+
+func (f *zipFile) Sync() error {
+	return nil
+}
+
+func (f *zipFile) Truncate(size int64) error {
+	return syscall.EPERM
+}
@@ -0,0 +1,124 @@
+package storage
+
+import (
+	"archive/zip"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+)
+
+func writeZip(t *testing.T, path string, files map[string]string) {
+	f, err := os.Create(path)
+	assert.NoError(t, err)
+	defer f.Close()
+
+	w := zip.NewWriter(f)
+	for name, content := range files {
+		entry, err := w.Create(name)
+		assert.NoError(t, err)
+		_, err = entry.Write([]byte(content))
+		assert.NoError(t, err)
+	}
+	assert.NoError(t, w.Close())
+}
+
+// seekingStore seeks the bodies that it is given to find their sizes as the S3 uploader does.
+type seekingStore struct {
+	*LocalObjectStore
+}
+
+func (s *seekingStore) Put(key string, r io.Reader) error {
+	if seeker, ok := r.(io.Seeker); ok {
+		if _, err := seeker.Seek(0, io.SeekEnd); err != nil {
+			return err
+		}
+		if _, err := seeker.Seek(0, io.SeekStart); err != nil {
+			return err
+		}
+	}
+	return s.LocalObjectStore.Put(key, r)
+}
+
+func TestZipFs(t *testing.T) {
+	root, err := ioutil.TempDir("", "zip-fs")
+	assert.NoError(t, err)
+	defer os.RemoveAll(root)
+
+	archivePath := filepath.Join(root, "archive.zip")
+	writeZip(t, archivePath, map[string]string{
+		"posts/your_posts_1.json":                "[]",
+		"photos_and_videos/album/1.json":         "{}",
+		"photos_and_videos/album/a.jpg":          "PHOTO",
+		"photos_and_videos/your_videos/b.mp4":    "VIDEO",
+		"../escaped.json":                        "{}",
+		"messages/inbox/alice_a1b2/photos/1.jpg": "PHOTO",
+	})
+
+	fs, err := OpenZipFs(archivePath)
+	assert.NoError(t, err)
+	defer fs.Close()
+
+	// dirs are implied by the paths of the files
+	isDir, err := afero.IsDir(fs, "photos_and_videos")
+	assert.NoError(t, err)
+	assert.True(t, isDir)
+	exists, err := afero.Exists(fs, "location")
+	assert.NoError(t, err)
+	assert.False(t, exists)
+
+	infos, err := afero.ReadDir(fs, "photos_and_videos")
+	assert.NoError(t, err)
+	if assert.Len(t, infos, 2) {
+		assert.Equal(t, "album", infos[0].Name())
+		assert.Equal(t, "your_videos", infos[1].Name())
+	}
+
+	data, err := afero.ReadFile(fs, "photos_and_videos/album/a.jpg")
+	assert.NoError(t, err)
+	assert.Equal(t, "PHOTO", string(data))
+
+	assert.Equal(t, []string{
+		"messages/inbox/alice_a1b2/photos/1.jpg",
+		"photos_and_videos/album/1.json",
+		"photos_and_videos/album/a.jpg",
+		"photos_and_videos/your_videos/b.mp4",
+		"posts/your_posts_1.json",
+	}, fs.Names())
+
+	assert.Error(t, afero.WriteFile(fs, "posts/your_posts_1.json", []byte("[]"), 0644))
+	assert.Error(t, fs.RemoveAll("posts"))
+
+	store := &seekingStore{NewLocalObjectStore(filepath.Join(root, "store"))}
+	assert.NoError(t, UploadFsExcept(fs, store, "user-a/fb_archives/1", "photos_and_videos", regexp.MustCompile(`^[0-9]+\.json$`)))
+	assert.NoError(t, UploadFsExcept(fs, store, "user-a/fb_archives/1", "files", nil))
+
+	keys, err := store.List("")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{
+		"user-a/fb_archives/1/photos_and_videos/album/a.jpg",
+		"user-a/fb_archives/1/photos_and_videos/your_videos/b.mp4",
+	}, keys)
+}
+
+func TestUploadOsFsToSeekingStore(t *testing.T) {
+	root, err := ioutil.TempDir("", "upload-dir")
+	assert.NoError(t, err)
+	defer os.RemoveAll(root)
+
+	dir := filepath.Join(root, "photos_and_videos")
+	assert.NoError(t, os.MkdirAll(dir, 0755))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "a.jpg"), []byte("PHOTO"), 0644))
+
+	store := &seekingStore{NewLocalObjectStore(filepath.Join(root, "store"))}
+	assert.NoError(t, UploadFsExcept(afero.NewOsFs(), store, "user-a/fb_archives/1", dir, nil))
+
+	data, err := ioutil.ReadFile(filepath.Join(root, "store", "user-a/fb_archives/1/photos_and_videos/a.jpg"))
+	assert.NoError(t, err)
+	assert.Equal(t, "PHOTO", string(data))
+}
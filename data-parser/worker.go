@@ -0,0 +1,291 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"strconv"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/endpoints"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/getsentry/sentry-go"
+	"github.com/jinzhu/gorm"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/bitmark-inc/datapod/data-parser/storage"
+)
+
+const (
+	minPollInterval = time.Second
+	maxPollInterval = time.Minute
+	reapInterval    = time.Minute
+)
+
+// backoff doubles the interval between polls while there is no task, up to max.
+type backoff struct {
+	min, max time.Duration
+	next     time.Duration
+}
+
+func (b *backoff) Next() time.Duration {
+	if b.next < b.min {
+		b.next = b.min
+	}
+	d := b.next
+	b.next *= 2
+	if b.next > b.max {
+		b.next = b.max
+	}
+	return d
+}
+
+func (b *backoff) Reset() {
+	b.next = b.min
+}
+
+type worker struct {
+	id     int
+	source storage.TaskSource
+	lease  time.Duration
+	// handle parses the archive of a claimed task, it is aborted once ctx is done
+	handle func(ctx context.Context, task *storage.Task) error
+}
+
+// run claims and handles tasks until claimCtx is done.
+// Running tasks are aborted and put back to pending once taskCtx is done.
+func (w *worker) run(claimCtx, taskCtx context.Context) {
+	contextLogger := log.WithField("worker", w.id)
+	b := &backoff{min: minPollInterval, max: maxPollInterval}
+
+	for {
+		if claimCtx.Err() != nil {
+			return
+		}
+
+		task, err := w.source.Claim(claimCtx)
+		if err != nil {
+			sentry.CaptureException(err)
+		}
+
+		if task == nil {
+			select {
+			case <-claimCtx.Done():
+				return
+			case <-time.After(b.Next()):
+			}
+			continue
+		}
+		b.Reset()
+
+		// the task is aborted once its lease is lost, as it may be handed out to another parser
+		handleCtx, abort := context.WithCancel(taskCtx)
+		leaseLost := w.heartbeat(handleCtx, task, abort)
+		err = w.handle(handleCtx, task)
+		abort()
+		if <-leaseLost {
+			contextLogger.WithField("task_id", task.ID).Warn("task lease lost")
+			continue
+		}
+
+		status := storage.TaskStatusFinished
+		if err != nil {
+			status = storage.TaskStatusFailed
+		}
+		if err != nil && taskCtx.Err() != nil {
+			// aborted by shutdown, let another worker pick it up. The task hasn't failed,
+			// so neither the error nor the pattern being parsed is recorded.
+			contextLogger.WithField("task_id", task.ID).Info("task requeued")
+			status = storage.TaskStatusPending
+			task.FailedPattern = ""
+			err = nil
+		}
+
+		if err := w.source.Complete(task, status, err); err != nil {
+			sentry.CaptureException(err)
+		}
+	}
+}
+
+// heartbeat renews the lease of the task periodically until ctx is done. If the lease is lost, the task
+// is aborted by calling abort. The returned channel tells if the lease is lost once the heartbeat stops.
+func (w *worker) heartbeat(ctx context.Context, task *storage.Task, abort context.CancelFunc) <-chan bool {
+	leaseLost := make(chan bool, 1)
+	go func() {
+		ticker := time.NewTicker(w.lease / 3)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				leaseLost <- false
+				return
+			case <-ticker.C:
+				err := w.source.Extend(task)
+				if err == storage.ErrLeaseLost {
+					abort()
+					leaseLost <- true
+					return
+				}
+				if err != nil {
+					sentry.CaptureException(err)
+				}
+			}
+		}
+	}()
+	return leaseLost
+}
+
+// reap puts the tasks abandoned by crashed parsers back to pending until ctx is done.
+func reap(ctx context.Context, db *gorm.DB, maxRetries int) {
+	ticker := time.NewTicker(reapInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			n, err := storage.ReapExpiredTasks(db, maxRetries)
+			if err != nil {
+				sentry.CaptureException(err)
+				continue
+			}
+			if n > 0 {
+				log.WithField("count", n).Info("expired tasks reaped")
+			}
+		}
+	}
+}
+
+// newTaskSource returns the task source set by DATA_PARSER_TASK_SOURCE.
+// The SQS queue is at AWS_SQS_QUEUE_URL, AWS_SQS_ENDPOINT overrides the endpoint for SQS compatible services.
+func newTaskSource(db *gorm.DB, lease time.Duration, maxRetries int) (storage.TaskSource, error) {
+	switch os.Getenv("DATA_PARSER_TASK_SOURCE") {
+	case "", "postgres":
+		return storage.NewPostgresTaskSource(db, lease), nil
+	case "sqs":
+		region := os.Getenv("AWS_REGION")
+		if region == "" {
+			region = endpoints.ApNortheast1RegionID
+		}
+		awsConfig := &aws.Config{Region: aws.String(region)}
+		if endpoint := os.Getenv("AWS_SQS_ENDPOINT"); endpoint != "" {
+			awsConfig.Endpoint = aws.String(endpoint)
+		}
+		sess, err := session.NewSession(awsConfig)
+		if err != nil {
+			return nil, err
+		}
+		queue := storage.NewSQS(sess, os.Getenv("AWS_SQS_QUEUE_URL"))
+		return storage.NewSQSTaskSource(queue, db, lease, maxRetries), nil
+	default:
+		return nil, fmt.Errorf("unknown task source: %s", os.Getenv("DATA_PARSER_TASK_SOURCE"))
+	}
+}
+
+// runWorker polls for pending tasks and parses their archives into Postgres
+// with DATA_PARSER_WORKERS workers. On SIGTERM it stops claiming tasks and waits
+// DATA_PARSER_SHUTDOWN_TIMEOUT for running tasks before requeuing them.
+// Running tasks are leased for DATA_PARSER_TASK_LEASE, tasks with expired leases are
+// retried up to DATA_PARSER_TASK_MAX_RETRIES times.
+// Invalid records are quarantined unless DATA_PARSER_STRICT is true, which fails the task instead.
+// Tasks are polled from Postgres unless DATA_PARSER_TASK_SOURCE is "sqs".
+// Each running parser must have a distinct DATA_PARSER_NODE_ID for generating row ids.
+func runWorker() {
+	postgresURI := os.Getenv("POSTGRES_URI")
+	workingDir := os.Getenv("DATA_PARSER_WORKING_DIR")
+
+	workers := 1
+	if s := os.Getenv("DATA_PARSER_WORKERS"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 1 {
+			log.Fatalf("invalid number of workers: %s", s)
+		}
+		workers = n
+	}
+
+	shutdownTimeout := time.Minute
+	if s := os.Getenv("DATA_PARSER_SHUTDOWN_TIMEOUT"); s != "" {
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			log.Fatalf("invalid shutdown timeout: %s", err)
+		}
+		shutdownTimeout = d
+	}
+
+	lease := 5 * time.Minute
+	if s := os.Getenv("DATA_PARSER_TASK_LEASE"); s != "" {
+		d, err := time.ParseDuration(s)
+		if err != nil || d <= 0 {
+			log.Fatalf("invalid task lease: %s", s)
+		}
+		lease = d
+	}
+
+	maxRetries := 3
+	if s := os.Getenv("DATA_PARSER_TASK_MAX_RETRIES"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 0 {
+			log.Fatalf("invalid max retries: %s", s)
+		}
+		maxRetries = n
+	}
+
+	strict := false
+	if s := os.Getenv("DATA_PARSER_STRICT"); s != "" {
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			log.Fatalf("invalid strict mode: %s", s)
+		}
+		strict = b
+	}
+
+	store, err := newObjectStore()
+	if err != nil {
+		panic(err)
+	}
+
+	ids, err := newIDGenerator()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	db := storage.NewPostgresORMDB(postgresURI)
+	defer db.Close()
+
+	source, err := newTaskSource(db, lease, maxRetries)
+	if err != nil {
+		panic(err)
+	}
+
+	claimCtx, stopClaiming := context.WithCancel(context.Background())
+	taskCtx, abortTasks := context.WithCancel(context.Background())
+	defer abortTasks()
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGTERM, syscall.SIGINT)
+	go func() {
+		<-signals
+		log.WithField("timeout", shutdownTimeout).Info("shutting down")
+		stopClaiming()
+		time.AfterFunc(shutdownTimeout, abortTasks)
+	}()
+
+	go reap(claimCtx, db, maxRetries)
+
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func(id int) {
+			defer wg.Done()
+			w := &worker{id: id, source: source, lease: lease, handle: func(ctx context.Context, task *storage.Task) error {
+				return handle(ctx, db, store, ids, workingDir, task, strict)
+			}}
+			w.run(claimCtx, taskCtx)
+		}(i)
+	}
+	wg.Wait()
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/bitmark-inc/datapod/data-parser/storage"
+)
+
+type completion struct {
+	taskID        string
+	status        storage.TaskStatusType
+	err           error
+	failedPattern string
+}
+
+// fakeSource hands out its tasks in order and sends the completions to completed.
+type fakeSource struct {
+	mu        sync.Mutex
+	tasks     []*storage.Task
+	extendErr error
+	completed chan completion
+}
+
+func newFakeSource(tasks ...*storage.Task) *fakeSource {
+	return &fakeSource{tasks: tasks, completed: make(chan completion, len(tasks))}
+}
+
+func (s *fakeSource) Claim(ctx context.Context) (*storage.Task, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.tasks) == 0 {
+		return nil, nil
+	}
+	task := s.tasks[0]
+	s.tasks = s.tasks[1:]
+	return task, nil
+}
+
+func (s *fakeSource) Extend(task *storage.Task) error {
+	return s.extendErr
+}
+
+func (s *fakeSource) Complete(task *storage.Task, status storage.TaskStatusType, taskErr error) error {
+	s.completed <- completion{task.ID, status, taskErr, task.FailedPattern}
+	return nil
+}
+
+// runInBackground runs the worker until the returned function is called.
+func runInBackground(w *worker, taskCtx context.Context) func() {
+	claimCtx, stopClaiming := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		w.run(claimCtx, taskCtx)
+		close(done)
+	}()
+	return func() {
+		stopClaiming()
+		<-done
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	b := &backoff{min: time.Second, max: 5 * time.Second}
+	assert.Equal(t, time.Second, b.Next())
+	assert.Equal(t, 2*time.Second, b.Next())
+	assert.Equal(t, 4*time.Second, b.Next())
+	assert.Equal(t, 5*time.Second, b.Next())
+	assert.Equal(t, 5*time.Second, b.Next())
+
+	b.Reset()
+	assert.Equal(t, time.Second, b.Next())
+}
+
+func TestWorkerCompletesTasks(t *testing.T) {
+	source := newFakeSource(&storage.Task{ID: "a"}, &storage.Task{ID: "b"})
+	w := &worker{source: source, lease: time.Minute, handle: func(ctx context.Context, task *storage.Task) error {
+		if task.ID == "b" {
+			return errors.New("broken archive")
+		}
+		return nil
+	}}
+	stop := runInBackground(w, context.Background())
+	defer stop()
+
+	assert.Equal(t, completion{"a", storage.TaskStatusFinished, nil, ""}, <-source.completed)
+	assert.Equal(t, completion{"b", storage.TaskStatusFailed, errors.New("broken archive"), ""}, <-source.completed)
+}
+
+func TestWorkerRequeuesTasksOnShutdown(t *testing.T) {
+	started := make(chan struct{})
+	source := newFakeSource(&storage.Task{ID: "a"})
+	w := &worker{source: source, lease: time.Minute, handle: func(ctx context.Context, task *storage.Task) error {
+		close(started)
+		<-ctx.Done()
+		task.FailedPattern = "posts"
+		return ctx.Err()
+	}}
+	taskCtx, abortTasks := context.WithCancel(context.Background())
+	stop := runInBackground(w, taskCtx)
+
+	<-started
+	abortTasks()
+	stop()
+
+	// the aborted task is put back to pending rather than failed, without an error
+	assert.Equal(t, completion{"a", storage.TaskStatusPending, nil, ""}, <-source.completed)
+}
+
+func TestWorkerAbortsTasksWithLostLeases(t *testing.T) {
+	aborted := make(chan struct{})
+	source := newFakeSource(&storage.Task{ID: "a"})
+	source.extendErr = storage.ErrLeaseLost
+	w := &worker{source: source, lease: 30 * time.Millisecond, handle: func(ctx context.Context, task *storage.Task) error {
+		<-ctx.Done()
+		close(aborted)
+		return ctx.Err()
+	}}
+	stop := runInBackground(w, context.Background())
+
+	<-aborted
+	stop()
+
+	// the task may be handed out to another parser, so it is left alone
+	assert.Len(t, source.completed, 0)
+}